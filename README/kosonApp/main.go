@@ -5,7 +5,6 @@ import (
 	"log"
 	"os"
 	"os/exec"
-	"strings"
 	"sync"
 	"time"
 
@@ -27,15 +26,6 @@ const target_name = "M_SHANGHAI" //"M_IZAR_ESP_TEST"
 
 //const target_name = "M_IZAR_TEST"
 
-func String_rm_char(a string, b string) string {
-	mac := ""
-	str := strings.Split(a, b)
-	for _, s := range str {
-		mac += s
-	}
-	return mac
-}
-
 //https://blog.csdn.net/weixin_44908159/article/details/123609779
 func mapdel(mac string) {
 	lock.Lock()
@@ -51,7 +41,7 @@ func mapadd(mac string) {
 	log.Printf("mapadd[%s]\r\n", mac)
 }
 func app1(dev *bluetooth.Device) {
-	mac := String_rm_char(dev.DevPath, ":")
+	mac := dev.Address().String()
 	//defer delete(appDevMap, mac)
 	defer mapdel(mac)
 	mapadd(mac)
@@ -108,7 +98,11 @@ NEXT:
 
 	//主动断开
 	log.Printf("[%s]Disconnected device...\r\n", mac)
-	go dev.Disconnect()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := dev.DisconnectContext(ctx); err != nil {
+		log.Printf("[%s]Disconnected Failed %+v\r\n", mac, err)
+	}
 	//err = dev.Disconnect()
 	//if err != nil {
 	//	log.Printf("[%s]Disconnected Failed %+v\r\n", mac, err.Error())
@@ -124,7 +118,7 @@ NEXT:
 }
 
 func app2(dev *bluetooth.Device) {
-	mac := String_rm_char(dev.DevPath, ":")
+	mac := dev.Address().String()
 	defer mapdel(mac)
 	mapadd(mac)
 
@@ -163,13 +157,8 @@ func app2(dev *bluetooth.Device) {
 		return
 	}
 
-	for {
-		time.Sleep(time.Microsecond * 10)
-		if !dev.IsConnected() {
-			log.Printf("[%s]Disconnected device...\r\n", mac)
-			return
-		}
-	}
+	<-dev.Disconnected()
+	log.Printf("[%s]Disconnected device...\r\n", mac)
 
 }
 
@@ -7,21 +7,34 @@
 package bluetooth
 
 import (
-	"errors"
 	"fmt"
+	"sync"
 
+	"github.com/godbus/dbus/v5"
 	"github.com/muka/go-bluetooth/api"
 	"github.com/muka/go-bluetooth/bluez/profile/adapter"
 	"github.com/muka/go-bluetooth/hw/linux"
 )
 
 type Adapter struct {
-	adapter              *adapter.Adapter1
+	adapter              *adapter.Adapter1 // use proxy() to read it
+	proxyLock            sync.RWMutex      // guards adapter and Mac
 	id                   string
 	Mac                  string
 	TargetName           string
 	cancelChan           chan struct{}
+	scanFilterSet        bool       // set once ScanPlus set the discovery filter
+	scanLock             sync.Mutex // guards cancelChan and scanFilterSet
 	defaultAdvertisement *Advertisement
+	advertisementLock    sync.Mutex // guards defaultAdvertisement
+	discovery            discoveryCoordinator
+	nameFilter           NameFilter
+	eviction             *cacheEvictor
+	evictionLock         sync.Mutex
+	resolver             *Resolver
+	resolverLock         sync.Mutex
+	connections          map[dbus.ObjectPath]*Device
+	connectionsLock      sync.Mutex
 
 	connectHandler func(device Addresser, connected bool)
 }
@@ -30,57 +43,108 @@ type Adapter struct {
 // first adapter available.
 //
 // Make sure to call Enable() before using it to initialize the adapter.
-var DefaultAdapter = &Adapter{
-	TargetName: "NO",
-	connectHandler: func(device Addresser, connected bool) {
-		return
-	},
-}
-var HCI1Adapter = &Adapter{
-	id:         "hci1",
-	TargetName: "NO",
-	connectHandler: func(device Addresser, connected bool) {
-		return
-	},
-}
+var DefaultAdapter = NewAdapter("")
+
+// HCI1Adapter is the adapter hci1. Use AdapterByID or AdapterByAddress to
+// select other adapters.
+var HCI1Adapter = NewAdapter("hci1")
 
 // Enable configures the BLE stack. It must be called before any
-// Bluetooth-related calls (unless otherwise indicated).
+// Bluetooth-related calls (unless otherwise indicated). It uses the adapter
+// selected with NewAdapter, AdapterByID, AdapterByAddress or SetHciId, or
+// the default adapter of BlueZ, usually hci0.
 func (a *Adapter) Enable() (err error) {
-	if a.id == "" {
-		a.adapter, err = api.GetDefaultAdapter()
-		if err != nil {
-			return err
-		}
-		a.id, err = a.adapter.GetAdapterID()
-		a.Mac = a.adapter.Properties.Address
-	} else { //说明a.id已经前置赋值
-		a.adapter, err = api.GetAdapter(a.id)
-		if err != nil {
-			return err
-		}
-		a.Mac = a.adapter.Properties.Address
+	a.discovery.lock.Lock()
+	if a.discovery.closed {
+		// The channel of the previous life is closed already.
+		a.discovery.done = nil
 	}
-	return nil
+	a.discovery.closed = false
+	a.discovery.lock.Unlock()
+	return a.enable()
 }
 
+// enable acquires the adapter proxy without reopening an adapter that was
+// closed with Close, for pools that enable adapters again after a hotplug.
+func (a *Adapter) enable() (err error) {
+	id := a.id
+	if id == "" {
+		id = api.GetDefaultAdapterID()
+	}
+	// The api package caches adapters and would return a stale proxy after
+	// bluetoothd restarted or the adapter was plugged in again.
+	proxy, err := adapter.GetAdapter(id)
+	if err != nil {
+		return err
+	}
+	a.id = id
+	a.setProxy(proxy)
+	return trackAdapter(a)
+}
+
+// proxy returns the BlueZ adapter object, or nil if the adapter is not
+// enabled. It changes when bluetoothd restarts.
+func (a *Adapter) proxy() *adapter.Adapter1 {
+	a.proxyLock.RLock()
+	defer a.proxyLock.RUnlock()
+	return a.adapter
+}
+
+// setProxy replaces the BlueZ adapter object.
+func (a *Adapter) setProxy(proxy *adapter.Adapter1) {
+	a.proxyLock.Lock()
+	defer a.proxyLock.Unlock()
+	a.adapter = proxy
+	a.Mac = proxy.Properties.Address
+}
+
+// ID returns the ID of the adapter, such as "hci0". It is empty for the
+// default adapter until Enable is called.
+func (a *Adapter) ID() string {
+	return a.id
+}
+
+// SetHciId selects the adapter with the given ID, such as "hci1", for the
+// next call to Enable.
 func (a *Adapter) SetHciId(id string) {
 	a.id = id
 }
 
+// SetTargetName only reports devices with exactly this local name in Scan
+// and ScanPlus. An empty name disables the filter. Use SetNameFilter for
+// prefix, glob or regular expression matching.
 func (a *Adapter) SetTargetName(name string) {
 	a.TargetName = name
+	a.nameFilter = NameFilter{}
+}
+
+// SetNameFilter only reports devices whose local name matches the filter in
+// Scan and ScanPlus. The filter is applied in this package and not by BlueZ,
+// so it also matches devices that send their name after the first
+// advertisement. It replaces the target name set with SetTargetName.
+func (a *Adapter) SetNameFilter(filter NameFilter) {
+	a.TargetName = ""
+	a.nameFilter = filter
+}
+
+// scanNameFilter returns the name filter to use while scanning.
+func (a *Adapter) scanNameFilter() NameFilter {
+	if a.TargetName != "" {
+		return NameFilter{mode: NameExact, pattern: a.TargetName}
+	}
+	return a.nameFilter
 }
 func (a *Adapter) Hello() {
 	fmt.Println("HELLO")
 }
 
 func (a *Adapter) Address() (MACAddress, error) {
-	if a.adapter == nil {
-		return MACAddress{}, errors.New("adapter not enabled")
+	p := a.proxy()
+	if p == nil {
+		return MACAddress{}, errAdapterNotEnabled
 	}
-	fmt.Println("a.adapter.Properties.Address", a.adapter.Properties.Address)
-	mac, err := ParseMAC(a.adapter.Properties.Address)
+	fmt.Println("a.adapter.Properties.Address", p.Properties.Address)
+	mac, err := ParseMAC(p.Properties.Address)
 	if err != nil {
 		return MACAddress{}, err
 	}
@@ -88,28 +152,25 @@ func (a *Adapter) Address() (MACAddress, error) {
 	return MACAddress{MAC: mac}, nil
 }
 
+// Enable2 enables the adapter with the given ID, unless another adapter was
+// selected before.
+//
+// Deprecated: select the adapter with NewAdapter, AdapterByID or SetHciId and
+// call Enable.
 func (a *Adapter) Enable2(hcix string) (err error) {
-	adapter.SetDefaultAdapterID(hcix) //仅仅增加一句话
 	if a.id == "" {
-		a.adapter, err = api.GetDefaultAdapter()
-		if err != nil {
-			return
-		}
-		a.id, err = a.adapter.GetAdapterID()
+		a.id = hcix
 	}
-	return nil
+	return a.Enable()
 }
 
+// Enable3 enables the adapter with the given ID, unless another adapter was
+// selected before.
+//
+// Deprecated: select the adapter with NewAdapter, AdapterByID or SetHciId and
+// call Enable.
 func (a *Adapter) Enable3(hcix string) (err error) {
-	//adapter.SetDefaultAdapterID(hcix)
-	if a.id == "" {
-		a.adapter, err = api.GetAdapter(hcix) //GetDefaultAdapter()
-		if err != nil {
-			return
-		}
-		a.id, err = a.adapter.GetAdapterID()
-	}
-	return nil
+	return a.Enable2(hcix)
 }
 
 //调用大哥的方法 优雅复位HCI
@@ -0,0 +1,206 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/muka/go-bluetooth/bluez"
+	"github.com/muka/go-bluetooth/bluez/profile/adapter"
+)
+
+var errAdapterNotFound = errors.New("bluetooth: adapter not found")
+
+// AdapterInfo describes a local Bluetooth adapter as listed by Adapters.
+type AdapterInfo struct {
+	// ID is the name of the adapter, such as "hci0".
+	ID string
+
+	// Address is the identity address of the adapter.
+	Address MACAddress
+
+	// Name is the system name of the adapter and Alias the name shown to
+	// remote devices.
+	Name  string
+	Alias string
+
+	// Powered is true when the adapter is switched on.
+	Powered bool
+}
+
+// makeAdapterInfo creates an AdapterInfo from the properties of an Adapter1
+// object.
+func makeAdapterInfo(path dbus.ObjectPath, props map[string]dbus.Variant) AdapterInfo {
+	// The path is known to be an adapter, so it can be parsed.
+	id, _ := adapter.ParseAdapterID(path)
+	info := AdapterInfo{ID: id}
+	if v, ok := props["Address"].Value().(string); ok {
+		info.Address.MAC, _ = ParseMAC(v)
+	}
+	if v, ok := props["AddressType"].Value().(string); ok {
+		info.Address.SetRandom(v == "random")
+	}
+	info.Name, _ = props["Name"].Value().(string)
+	info.Alias, _ = props["Alias"].Value().(string)
+	info.Powered, _ = props["Powered"].Value().(bool)
+	return info
+}
+
+// Adapters returns all Bluetooth adapters known to BlueZ, sorted by ID.
+func Adapters() ([]AdapterInfo, error) {
+	om, err := bluez.GetObjectManager()
+	if err != nil {
+		return nil, err
+	}
+	objects, err := om.GetManagedObjects()
+	if err != nil {
+		return nil, err
+	}
+	var infos []AdapterInfo
+	for path, ifaces := range objects {
+		props, ok := ifaces["org.bluez.Adapter1"]
+		if !ok {
+			continue
+		}
+		infos = append(infos, makeAdapterInfo(path, props))
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+	return infos, nil
+}
+
+// NewAdapter returns the adapter with the given ID, such as "hci1". It is not
+// checked whether the adapter exists until Enable is called.
+func NewAdapter(id string) *Adapter {
+	return &Adapter{
+		id: id,
+		connectHandler: func(device Addresser, connected bool) {
+			return
+		},
+	}
+}
+
+// AdapterByID returns the adapter with the given ID, such as "hci1", if it
+// exists. Call Enable before using it.
+func AdapterByID(id string) (*Adapter, error) {
+	infos, err := Adapters()
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if info.ID == id {
+			return NewAdapter(id), nil
+		}
+	}
+	return nil, errAdapterNotFound
+}
+
+// AdapterByAddress returns the adapter with the given address, in
+// 11:22:33:AA:BB:CC format. This is useful for USB adapters, which may get a
+// different ID each time they are plugged in. Call Enable before using it.
+func AdapterByAddress(address string) (*Adapter, error) {
+	mac, err := ParseMAC(address)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := Adapters()
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if info.Address.MAC == mac {
+			return NewAdapter(info.ID), nil
+		}
+	}
+	return nil, errAdapterNotFound
+}
+
+// AdapterHotplugEventType is the type of an AdapterHotplugEvent.
+type AdapterHotplugEventType uint8
+
+const (
+	// AdapterAdded is sent when an adapter appears, for example when a USB
+	// adapter is plugged in.
+	AdapterAdded AdapterHotplugEventType = iota + 1
+
+	// AdapterRemoved is sent when an adapter disappears. Only the ID of the
+	// adapter is known.
+	AdapterRemoved
+)
+
+// AdapterHotplugEvent is sent by WatchAdapters when an adapter is added or
+// removed.
+type AdapterHotplugEvent struct {
+	Type    AdapterHotplugEventType
+	Adapter AdapterInfo
+}
+
+// WatchAdapters returns a channel that receives an event whenever an adapter
+// is added or removed. The channel is closed when the context is canceled.
+func WatchAdapters(ctx context.Context) (<-chan AdapterHotplugEvent, error) {
+	signal, cancel, err := watchSignals(signalFilter{
+		Path:      "/",
+		Interface: "org.freedesktop.DBus.ObjectManager",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan AdapterHotplugEvent, 4)
+	go func() {
+		defer close(events)
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signal:
+				event, ok := makeAdapterHotplugEvent(sig)
+				if !ok {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// makeAdapterHotplugEvent converts an InterfacesAdded or InterfacesRemoved
+// signal for an Adapter1 object into an event.
+func makeAdapterHotplugEvent(sig *dbus.Signal) (AdapterHotplugEvent, bool) {
+	if sig == nil || len(sig.Body) < 2 {
+		return AdapterHotplugEvent{}, false
+	}
+	path, ok := sig.Body[0].(dbus.ObjectPath)
+	if !ok {
+		return AdapterHotplugEvent{}, false
+	}
+	switch sig.Name {
+	case "org.freedesktop.DBus.ObjectManager.InterfacesAdded":
+		ifaces, _ := sig.Body[1].(map[string]map[string]dbus.Variant)
+		props, ok := ifaces["org.bluez.Adapter1"]
+		if !ok {
+			return AdapterHotplugEvent{}, false
+		}
+		return AdapterHotplugEvent{AdapterAdded, makeAdapterInfo(path, props)}, true
+	case "org.freedesktop.DBus.ObjectManager.InterfacesRemoved":
+		ifaces, _ := sig.Body[1].([]string)
+		for _, iface := range ifaces {
+			if iface == "org.bluez.Adapter1" {
+				id, _ := adapter.ParseAdapterID(path)
+				return AdapterHotplugEvent{AdapterRemoved, AdapterInfo{ID: id}}, true
+			}
+		}
+	}
+	return AdapterHotplugEvent{}, false
+}
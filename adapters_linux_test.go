@@ -0,0 +1,48 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestMakeAdapterHotplugEvent(t *testing.T) {
+	added := &dbus.Signal{
+		Name: "org.freedesktop.DBus.ObjectManager.InterfacesAdded",
+		Body: []interface{}{
+			dbus.ObjectPath("/org/bluez/hci1"),
+			map[string]map[string]dbus.Variant{
+				"org.bluez.Adapter1": {
+					"Address": dbus.MakeVariant("00:1a:7d:da:71:13"),
+					"Alias":   dbus.MakeVariant("gateway"),
+					"Powered": dbus.MakeVariant(true),
+				},
+			},
+		},
+	}
+	event, ok := makeAdapterHotplugEvent(added)
+	if !ok || event.Type != AdapterAdded || event.Adapter.ID != "hci1" ||
+		event.Adapter.Address.String() != "00:1A:7D:DA:71:13" || event.Adapter.Alias != "gateway" || !event.Adapter.Powered {
+		t.Errorf("unexpected event %+v, %v", event, ok)
+	}
+
+	removed := &dbus.Signal{
+		Name: "org.freedesktop.DBus.ObjectManager.InterfacesRemoved",
+		Body: []interface{}{dbus.ObjectPath("/org/bluez/hci1"), []string{"org.bluez.Adapter1", "org.bluez.GattManager1"}},
+	}
+	event, ok = makeAdapterHotplugEvent(removed)
+	if !ok || event.Type != AdapterRemoved || event.Adapter.ID != "hci1" {
+		t.Errorf("unexpected event %+v, %v", event, ok)
+	}
+
+	device := &dbus.Signal{
+		Name: "org.freedesktop.DBus.ObjectManager.InterfacesRemoved",
+		Body: []interface{}{dbus.ObjectPath("/org/bluez/hci1/dev_11_22_33_44_55_66"), []string{"org.bluez.Device1"}},
+	}
+	if event, ok := makeAdapterHotplugEvent(device); ok {
+		t.Errorf("unexpected event for a device: %+v", event)
+	}
+}
@@ -0,0 +1,301 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+var errAdapterNotEnabled = errors.New("bluetooth: adapter not enabled")
+
+// AdapterEvent is a property change of the local adapter as sent by
+// Adapter.WatchAdapter. It is one of PoweredChanged, DiscoverableChanged,
+// PairableChanged, DiscoveringChanged, DiscoverableTimeoutChanged,
+// PairableTimeoutChanged, ClassChanged, NameChanged, AliasChanged or
+// UUIDsChanged.
+type AdapterEvent interface {
+	adapterEvent()
+}
+
+// PoweredChanged is sent when the adapter is switched on or off.
+type PoweredChanged struct {
+	Powered bool
+}
+
+// DiscoverableChanged is sent when the adapter becomes (un)discoverable,
+// including when the discoverable timeout expires.
+type DiscoverableChanged struct {
+	Discoverable bool
+}
+
+// PairableChanged is sent when the adapter becomes (un)pairable, including
+// when the pairable timeout expires.
+type PairableChanged struct {
+	Pairable bool
+}
+
+// DiscoveringChanged is sent when discovery starts or stops.
+type DiscoveringChanged struct {
+	Discovering bool
+}
+
+// DiscoverableTimeoutChanged is sent when the discoverable timeout changes.
+// A timeout of 0 means the adapter stays discoverable.
+type DiscoverableTimeoutChanged struct {
+	Timeout time.Duration
+}
+
+// PairableTimeoutChanged is sent when the pairable timeout changes. A
+// timeout of 0 means the adapter stays pairable.
+type PairableTimeoutChanged struct {
+	Timeout time.Duration
+}
+
+// ClassChanged is sent when the class of device of the adapter changes.
+type ClassChanged struct {
+	Class uint32
+}
+
+func (PoweredChanged) adapterEvent()             {}
+func (DiscoverableChanged) adapterEvent()        {}
+func (PairableChanged) adapterEvent()            {}
+func (DiscoveringChanged) adapterEvent()         {}
+func (DiscoverableTimeoutChanged) adapterEvent() {}
+func (PairableTimeoutChanged) adapterEvent()     {}
+func (ClassChanged) adapterEvent()               {}
+func (NameChanged) adapterEvent()                {}
+func (AliasChanged) adapterEvent()               {}
+func (UUIDsChanged) adapterEvent()               {}
+
+// makeAdapterEvent converts a changed Adapter1 property into an
+// AdapterEvent. It returns nil for properties that have no event type.
+func makeAdapterEvent(name string, val dbus.Variant) AdapterEvent {
+	switch v := val.Value().(type) {
+	case bool:
+		switch name {
+		case "Powered":
+			return PoweredChanged{v}
+		case "Discoverable":
+			return DiscoverableChanged{v}
+		case "Pairable":
+			return PairableChanged{v}
+		case "Discovering":
+			return DiscoveringChanged{v}
+		}
+	case uint32:
+		switch name {
+		case "DiscoverableTimeout":
+			return DiscoverableTimeoutChanged{time.Duration(v) * time.Second}
+		case "PairableTimeout":
+			return PairableTimeoutChanged{time.Duration(v) * time.Second}
+		case "Class":
+			return ClassChanged{v}
+		}
+	case string:
+		switch name {
+		case "Name":
+			return NameChanged{v}
+		case "Alias":
+			return AliasChanged{v}
+		}
+	case []string:
+		if name == "UUIDs" {
+			return UUIDsChanged{parseUUIDs(v)}
+		}
+	}
+	return nil
+}
+
+// parseUUIDs parses the UUIDs in a BlueZ UUIDs property.
+func parseUUIDs(s []string) []UUID {
+	var uuids []UUID
+	for _, str := range s {
+		// Assume the UUID is well-formed.
+		uuid, _ := ParseUUID(str)
+		uuids = append(uuids, uuid)
+	}
+	return uuids
+}
+
+// timeoutSeconds converts a timeout to whole seconds as used by BlueZ,
+// rounding up.
+func timeoutSeconds(timeout time.Duration) uint32 {
+	if timeout <= 0 {
+		return 0
+	}
+	return uint32((timeout + time.Second - 1) / time.Second)
+}
+
+// Powered returns whether the adapter is switched on.
+func (a *Adapter) Powered() (bool, error) {
+	p := a.proxy()
+	if p == nil {
+		return false, errAdapterNotEnabled
+	}
+	return p.GetPowered()
+}
+
+// SetPowered switches the adapter on or off.
+func (a *Adapter) SetPowered(powered bool) error {
+	p := a.proxy()
+	if p == nil {
+		return errAdapterNotEnabled
+	}
+	return p.SetPowered(powered)
+}
+
+// Name returns the system name of the adapter, which cannot be changed from
+// here. Use Alias for the name that is shown to remote devices.
+func (a *Adapter) Name() (string, error) {
+	p := a.proxy()
+	if p == nil {
+		return "", errAdapterNotEnabled
+	}
+	return p.GetName()
+}
+
+// Alias returns the name of the adapter as shown to remote devices.
+func (a *Adapter) Alias() (string, error) {
+	p := a.proxy()
+	if p == nil {
+		return "", errAdapterNotEnabled
+	}
+	return p.GetAlias()
+}
+
+// SetAlias changes the name of the adapter as shown to remote devices. An
+// empty alias resets it to the system name.
+func (a *Adapter) SetAlias(alias string) error {
+	p := a.proxy()
+	if p == nil {
+		return errAdapterNotEnabled
+	}
+	return p.SetAlias(alias)
+}
+
+// SetDiscoverable makes the adapter (un)discoverable for classic Bluetooth
+// inquiry. BlueZ switches it back off after the timeout, which is rounded up
+// to whole seconds; a timeout of 0 keeps it discoverable.
+func (a *Adapter) SetDiscoverable(discoverable bool, timeout time.Duration) error {
+	p := a.proxy()
+	if p == nil {
+		return errAdapterNotEnabled
+	}
+	if discoverable {
+		// Set the timeout first, as it starts when Discoverable is set.
+		err := p.SetDiscoverableTimeout(timeoutSeconds(timeout))
+		if err != nil {
+			return err
+		}
+	}
+	return p.SetDiscoverable(discoverable)
+}
+
+// SetPairable allows or rejects pairing with the adapter. BlueZ switches it
+// back off after the timeout, which is rounded up to whole seconds; a timeout
+// of 0 keeps it pairable.
+func (a *Adapter) SetPairable(pairable bool, timeout time.Duration) error {
+	p := a.proxy()
+	if p == nil {
+		return errAdapterNotEnabled
+	}
+	if pairable {
+		// Set the timeout first, as it starts when Pairable is set.
+		err := p.SetPairableTimeout(timeoutSeconds(timeout))
+		if err != nil {
+			return err
+		}
+	}
+	return p.SetPairable(pairable)
+}
+
+// Class returns the class of device of the adapter.
+func (a *Adapter) Class() (uint32, error) {
+	p := a.proxy()
+	if p == nil {
+		return 0, errAdapterNotEnabled
+	}
+	return p.GetClass()
+}
+
+// UUIDs returns the UUIDs of the services the adapter offers.
+func (a *Adapter) UUIDs() ([]UUID, error) {
+	p := a.proxy()
+	if p == nil {
+		return nil, errAdapterNotEnabled
+	}
+	uuids, err := p.GetUUIDs()
+	if err != nil {
+		return nil, err
+	}
+	return parseUUIDs(uuids), nil
+}
+
+// Modalias returns the remote device ID information of the adapter in modalias
+// format, for example "usb:v1D6Bp0246d0537".
+func (a *Adapter) Modalias() (string, error) {
+	p := a.proxy()
+	if p == nil {
+		return "", errAdapterNotEnabled
+	}
+	return p.GetModalias()
+}
+
+// Discovering returns whether the adapter is discovering devices, whether
+// started from this package or by another process.
+func (a *Adapter) Discovering() (bool, error) {
+	p := a.proxy()
+	if p == nil {
+		return false, errAdapterNotEnabled
+	}
+	return p.GetDiscovering()
+}
+
+// WatchAdapter returns a channel with property changes of the adapter, for
+// example when it is powered off or another process starts discovery. The
+// channel is closed when the context is canceled.
+func (a *Adapter) WatchAdapter(ctx context.Context) (<-chan AdapterEvent, error) {
+	p := a.proxy()
+	if p == nil {
+		return nil, errAdapterNotEnabled
+	}
+	path := p.Path()
+	signal, cancel, err := watchPropertiesChanged(path)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan AdapterEvent, 16)
+	go func() {
+		defer close(events)
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signal:
+				changes, ok := propertiesChangedFor(sig, path, "org.bluez.Adapter1")
+				if !ok {
+					continue
+				}
+				for name, val := range changes {
+					event := makeAdapterEvent(name, val)
+					if event == nil {
+						continue
+					}
+					select {
+					case events <- event:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+	return events, nil
+}
@@ -0,0 +1,46 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestMakeAdapterEvent(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		value interface{}
+		event AdapterEvent
+	}{
+		{"Powered", false, PoweredChanged{false}},
+		{"Discovering", true, DiscoveringChanged{true}},
+		{"DiscoverableTimeout", uint32(180), DiscoverableTimeoutChanged{3 * time.Minute}},
+		{"Alias", "gateway", AliasChanged{"gateway"}},
+		{"UUIDs", []string{"0000180a-0000-1000-8000-00805f9b34fb"}, UUIDsChanged{[]UUID{New16BitUUID(0x180a)}}},
+		{"Address", "00:1A:7D:DA:71:13", nil},
+	} {
+		event := makeAdapterEvent(tc.name, dbus.MakeVariant(tc.value))
+		if !reflect.DeepEqual(event, tc.event) {
+			t.Errorf("%s: expected %#v but got %#v", tc.name, tc.event, event)
+		}
+	}
+}
+
+func TestTimeoutSeconds(t *testing.T) {
+	for timeout, want := range map[time.Duration]uint32{
+		0:                       0,
+		-time.Second:            0,
+		time.Second:             1,
+		1500 * time.Millisecond: 2,
+		3 * time.Minute:         180,
+	} {
+		if got := timeoutSeconds(timeout); got != want {
+			t.Errorf("%s: expected %d but got %d", timeout, want, got)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Code generated by bin/gen-appearance; DO NOT EDIT.
+// This file was generated on 2026-10-19 12:18:14.083523811 +0000 UTC m=+0.000737301 from data/gap_appearance.json, a curated
+// subset of the appearance values in
+// https://github.com/NordicSemiconductor/bluetooth-numbers-database/blob/master/v1/gap_appearance.json
+// Values outside the subset are treated as unknown.
+
+package bluetooth
+
+const (
+
+	// AppearanceUnknown - Unknown
+	AppearanceUnknown Appearance = 0x0000
+
+	// AppearancePhone - Phone
+	AppearancePhone Appearance = 0x0040
+
+	// AppearanceComputer - Computer
+	AppearanceComputer Appearance = 0x0080
+
+	// AppearanceWatch - Watch
+	AppearanceWatch Appearance = 0x00C0
+
+	// AppearanceWatchSportsWatch - Sports Watch
+	AppearanceWatchSportsWatch Appearance = 0x00C1
+
+	// AppearanceWatchSmartwatch - Smartwatch
+	AppearanceWatchSmartwatch Appearance = 0x00C2
+
+	// AppearanceClock - Clock
+	AppearanceClock Appearance = 0x0100
+
+	// AppearanceDisplay - Display
+	AppearanceDisplay Appearance = 0x0140
+
+	// AppearanceRemoteControl - Remote Control
+	AppearanceRemoteControl Appearance = 0x0180
+
+	// AppearanceEyeGlasses - Eye-glasses
+	AppearanceEyeGlasses Appearance = 0x01C0
+
+	// AppearanceTag - Tag
+	AppearanceTag Appearance = 0x0200
+
+	// AppearanceKeyring - Keyring
+	AppearanceKeyring Appearance = 0x0240
+
+	// AppearanceMediaPlayer - Media Player
+	AppearanceMediaPlayer Appearance = 0x0280
+
+	// AppearanceBarcodeScanner - Barcode Scanner
+	AppearanceBarcodeScanner Appearance = 0x02C0
+
+	// AppearanceThermometer - Thermometer
+	AppearanceThermometer Appearance = 0x0300
+
+	// AppearanceThermometerEarThermometer - Ear Thermometer
+	AppearanceThermometerEarThermometer Appearance = 0x0301
+
+	// AppearanceHeartRateSensor - Heart Rate Sensor
+	AppearanceHeartRateSensor Appearance = 0x0340
+
+	// AppearanceHeartRateSensorHeartRateBelt - Heart Rate Belt
+	AppearanceHeartRateSensorHeartRateBelt Appearance = 0x0341
+
+	// AppearanceBloodPressure - Blood Pressure
+	AppearanceBloodPressure Appearance = 0x0380
+
+	// AppearanceBloodPressureArmBloodPressure - Arm Blood Pressure
+	AppearanceBloodPressureArmBloodPressure Appearance = 0x0381
+
+	// AppearanceBloodPressureWristBloodPressure - Wrist Blood Pressure
+	AppearanceBloodPressureWristBloodPressure Appearance = 0x0382
+
+	// AppearanceHumanInterfaceDevice - Human Interface Device
+	AppearanceHumanInterfaceDevice Appearance = 0x03C0
+
+	// AppearanceHumanInterfaceDeviceKeyboard - Keyboard
+	AppearanceHumanInterfaceDeviceKeyboard Appearance = 0x03C1
+
+	// AppearanceHumanInterfaceDeviceMouse - Mouse
+	AppearanceHumanInterfaceDeviceMouse Appearance = 0x03C2
+
+	// AppearanceHumanInterfaceDeviceJoystick - Joystick
+	AppearanceHumanInterfaceDeviceJoystick Appearance = 0x03C3
+
+	// AppearanceHumanInterfaceDeviceGamepad - Gamepad
+	AppearanceHumanInterfaceDeviceGamepad Appearance = 0x03C4
+
+	// AppearanceHumanInterfaceDeviceDigitizerTablet - Digitizer Tablet
+	AppearanceHumanInterfaceDeviceDigitizerTablet Appearance = 0x03C5
+
+	// AppearanceHumanInterfaceDeviceCardReader - Card Reader
+	AppearanceHumanInterfaceDeviceCardReader Appearance = 0x03C6
+
+	// AppearanceHumanInterfaceDeviceDigitalPen - Digital Pen
+	AppearanceHumanInterfaceDeviceDigitalPen Appearance = 0x03C7
+
+	// AppearanceHumanInterfaceDeviceBarcodeScanner - Barcode Scanner
+	AppearanceHumanInterfaceDeviceBarcodeScanner Appearance = 0x03C8
+
+	// AppearanceGlucoseMeter - Glucose Meter
+	AppearanceGlucoseMeter Appearance = 0x0400
+
+	// AppearanceRunningWalkingSensor - Running Walking Sensor
+	AppearanceRunningWalkingSensor Appearance = 0x0440
+
+	// AppearanceRunningWalkingSensorInShoeRunningWalkingSensor - In-Shoe Running Walking Sensor
+	AppearanceRunningWalkingSensorInShoeRunningWalkingSensor Appearance = 0x0441
+
+	// AppearanceRunningWalkingSensorOnShoeRunningWalkingSensor - On-Shoe Running Walking Sensor
+	AppearanceRunningWalkingSensorOnShoeRunningWalkingSensor Appearance = 0x0442
+
+	// AppearanceRunningWalkingSensorOnHipRunningWalkingSensor - On-Hip Running Walking Sensor
+	AppearanceRunningWalkingSensorOnHipRunningWalkingSensor Appearance = 0x0443
+
+	// AppearanceCycling - Cycling
+	AppearanceCycling Appearance = 0x0480
+
+	// AppearanceCyclingCyclingComputer - Cycling Computer
+	AppearanceCyclingCyclingComputer Appearance = 0x0481
+
+	// AppearanceCyclingSpeedSensor - Speed Sensor
+	AppearanceCyclingSpeedSensor Appearance = 0x0482
+
+	// AppearanceCyclingCadenceSensor - Cadence Sensor
+	AppearanceCyclingCadenceSensor Appearance = 0x0483
+
+	// AppearanceCyclingPowerSensor - Power Sensor
+	AppearanceCyclingPowerSensor Appearance = 0x0484
+
+	// AppearanceCyclingSpeedAndCadenceSensor - Speed and Cadence Sensor
+	AppearanceCyclingSpeedAndCadenceSensor Appearance = 0x0485
+
+	// AppearancePulseOximeter - Pulse Oximeter
+	AppearancePulseOximeter Appearance = 0x0C40
+
+	// AppearancePulseOximeterFingertip - Fingertip
+	AppearancePulseOximeterFingertip Appearance = 0x0C41
+
+	// AppearancePulseOximeterWristWorn - Wrist Worn
+	AppearancePulseOximeterWristWorn Appearance = 0x0C42
+
+	// AppearanceWeightScale - Weight Scale
+	AppearanceWeightScale Appearance = 0x0C80
+
+	// AppearancePersonalMobilityDevice - Personal Mobility Device
+	AppearancePersonalMobilityDevice Appearance = 0x0CC0
+
+	// AppearanceContinuousGlucoseMonitor - Continuous Glucose Monitor
+	AppearanceContinuousGlucoseMonitor Appearance = 0x0D00
+
+	// AppearanceInsulinPump - Insulin Pump
+	AppearanceInsulinPump Appearance = 0x0D40
+
+	// AppearanceMedicationDelivery - Medication Delivery
+	AppearanceMedicationDelivery Appearance = 0x0D80
+
+	// AppearanceOutdoorSportsActivity - Outdoor Sports Activity
+	AppearanceOutdoorSportsActivity Appearance = 0x1440
+
+	// AppearanceOutdoorSportsActivityLocationDisplay - Location Display
+	AppearanceOutdoorSportsActivityLocationDisplay Appearance = 0x1441
+
+	// AppearanceOutdoorSportsActivityLocationAndNavigationDisplay - Location and Navigation Display
+	AppearanceOutdoorSportsActivityLocationAndNavigationDisplay Appearance = 0x1442
+
+	// AppearanceOutdoorSportsActivityLocationPod - Location Pod
+	AppearanceOutdoorSportsActivityLocationPod Appearance = 0x1443
+
+	// AppearanceOutdoorSportsActivityLocationAndNavigationPod - Location and Navigation Pod
+	AppearanceOutdoorSportsActivityLocationAndNavigationPod Appearance = 0x1444
+)
+
+// appearanceNames maps the appearance values to their names. Values without a
+// subcategory have the name of the category.
+var appearanceNames = map[Appearance]string{
+	AppearanceUnknown:                                           "Unknown",
+	AppearancePhone:                                             "Phone",
+	AppearanceComputer:                                          "Computer",
+	AppearanceWatch:                                             "Watch",
+	AppearanceWatchSportsWatch:                                  "Sports Watch",
+	AppearanceWatchSmartwatch:                                   "Smartwatch",
+	AppearanceClock:                                             "Clock",
+	AppearanceDisplay:                                           "Display",
+	AppearanceRemoteControl:                                     "Remote Control",
+	AppearanceEyeGlasses:                                        "Eye-glasses",
+	AppearanceTag:                                               "Tag",
+	AppearanceKeyring:                                           "Keyring",
+	AppearanceMediaPlayer:                                       "Media Player",
+	AppearanceBarcodeScanner:                                    "Barcode Scanner",
+	AppearanceThermometer:                                       "Thermometer",
+	AppearanceThermometerEarThermometer:                         "Ear Thermometer",
+	AppearanceHeartRateSensor:                                   "Heart Rate Sensor",
+	AppearanceHeartRateSensorHeartRateBelt:                      "Heart Rate Belt",
+	AppearanceBloodPressure:                                     "Blood Pressure",
+	AppearanceBloodPressureArmBloodPressure:                     "Arm Blood Pressure",
+	AppearanceBloodPressureWristBloodPressure:                   "Wrist Blood Pressure",
+	AppearanceHumanInterfaceDevice:                              "Human Interface Device",
+	AppearanceHumanInterfaceDeviceKeyboard:                      "Keyboard",
+	AppearanceHumanInterfaceDeviceMouse:                         "Mouse",
+	AppearanceHumanInterfaceDeviceJoystick:                      "Joystick",
+	AppearanceHumanInterfaceDeviceGamepad:                       "Gamepad",
+	AppearanceHumanInterfaceDeviceDigitizerTablet:               "Digitizer Tablet",
+	AppearanceHumanInterfaceDeviceCardReader:                    "Card Reader",
+	AppearanceHumanInterfaceDeviceDigitalPen:                    "Digital Pen",
+	AppearanceHumanInterfaceDeviceBarcodeScanner:                "Barcode Scanner",
+	AppearanceGlucoseMeter:                                      "Glucose Meter",
+	AppearanceRunningWalkingSensor:                              "Running Walking Sensor",
+	AppearanceRunningWalkingSensorInShoeRunningWalkingSensor:    "In-Shoe Running Walking Sensor",
+	AppearanceRunningWalkingSensorOnShoeRunningWalkingSensor:    "On-Shoe Running Walking Sensor",
+	AppearanceRunningWalkingSensorOnHipRunningWalkingSensor:     "On-Hip Running Walking Sensor",
+	AppearanceCycling:                                           "Cycling",
+	AppearanceCyclingCyclingComputer:                            "Cycling Computer",
+	AppearanceCyclingSpeedSensor:                                "Speed Sensor",
+	AppearanceCyclingCadenceSensor:                              "Cadence Sensor",
+	AppearanceCyclingPowerSensor:                                "Power Sensor",
+	AppearanceCyclingSpeedAndCadenceSensor:                      "Speed and Cadence Sensor",
+	AppearancePulseOximeter:                                     "Pulse Oximeter",
+	AppearancePulseOximeterFingertip:                            "Fingertip",
+	AppearancePulseOximeterWristWorn:                            "Wrist Worn",
+	AppearanceWeightScale:                                       "Weight Scale",
+	AppearancePersonalMobilityDevice:                            "Personal Mobility Device",
+	AppearanceContinuousGlucoseMonitor:                          "Continuous Glucose Monitor",
+	AppearanceInsulinPump:                                       "Insulin Pump",
+	AppearanceMedicationDelivery:                                "Medication Delivery",
+	AppearanceOutdoorSportsActivity:                             "Outdoor Sports Activity",
+	AppearanceOutdoorSportsActivityLocationDisplay:              "Location Display",
+	AppearanceOutdoorSportsActivityLocationAndNavigationDisplay: "Location and Navigation Display",
+	AppearanceOutdoorSportsActivityLocationPod:                  "Location Pod",
+	AppearanceOutdoorSportsActivityLocationAndNavigationPod:     "Location and Navigation Pod",
+}
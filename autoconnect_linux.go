@@ -0,0 +1,150 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// AutoConnectOptions configures an AutoConnector.
+type AutoConnectOptions struct {
+	// Match selects the devices to connect to. A ScanMatch can be used here
+	// by passing its Matches method. If nil, every device is connected.
+	Match func(ScanResult) bool
+
+	// MaxConcurrent limits the number of devices that are connected (or
+	// being connected) at the same time. Zero means no limit.
+	MaxConcurrent int
+
+	// Cooldown is the minimum time between the end of a connection (or a
+	// failed attempt) to a device and the next attempt to the same device.
+	Cooldown time.Duration
+
+	// ResolveTimeout limits the time to wait for services to be resolved
+	// after connecting. The default is ten seconds.
+	ResolveTimeout time.Duration
+
+	// Params are passed to Adapter.Connect.
+	Params ConnectionParams
+
+	// Ready is called in a new goroutine once a device is connected and its
+	// services are resolved. The connection slot is released when the device
+	// disconnects, so Ready (or the application) must eventually disconnect.
+	Ready func(*Device)
+
+	// Failed is called when a connection attempt failed. It is optional.
+	Failed func(address Address, err error)
+}
+
+// AutoConnector connects to devices as they are found during a scan. It
+// replaces the fixed behavior of ScanPlus with a configurable policy.
+type AutoConnector struct {
+	adapter *Adapter
+	options AutoConnectOptions
+
+	lock        sync.Mutex
+	active      map[MAC]struct{}
+	lastAttempt map[MAC]time.Time
+}
+
+// NewAutoConnector returns a new AutoConnector for this adapter. Pass its
+// HandleScanResult method to Scan, or call Run.
+func (a *Adapter) NewAutoConnector(options AutoConnectOptions) *AutoConnector {
+	if options.ResolveTimeout <= 0 {
+		options.ResolveTimeout = 10 * time.Second
+	}
+	return &AutoConnector{
+		adapter:     a,
+		options:     options,
+		active:      make(map[MAC]struct{}),
+		lastAttempt: make(map[MAC]time.Time),
+	}
+}
+
+// Run scans with the given discovery filter and connects to matching devices
+// until StopScan is called.
+func (c *AutoConnector) Run(filter map[string]interface{}) error {
+	return c.adapter.Scan(filter, c.HandleScanResult)
+}
+
+// Active returns the number of devices that are connected or being connected
+// by this AutoConnector.
+func (c *AutoConnector) Active() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.active)
+}
+
+// HandleScanResult starts a connection to the device in the scan result if it
+// matches and the limits allow it. It does not block.
+func (c *AutoConnector) HandleScanResult(adapter *Adapter, result ScanResult) {
+	if c.options.Match != nil && !c.options.Match(result) {
+		return
+	}
+	address, ok := makeAddress(result.Address)
+	if !ok {
+		return
+	}
+
+	c.lock.Lock()
+	if _, ok := c.active[address.MAC]; ok {
+		c.lock.Unlock()
+		return
+	}
+	if c.options.MaxConcurrent > 0 && len(c.active) >= c.options.MaxConcurrent {
+		c.lock.Unlock()
+		return
+	}
+	if last, ok := c.lastAttempt[address.MAC]; ok {
+		if time.Since(last) < c.options.Cooldown {
+			c.lock.Unlock()
+			return
+		}
+		delete(c.lastAttempt, address.MAC)
+	}
+	c.active[address.MAC] = struct{}{}
+	c.lock.Unlock()
+
+	go c.connect(address)
+}
+
+// connect connects to a single device and holds its slot until it
+// disconnects.
+func (c *AutoConnector) connect(address Address) {
+	defer func() {
+		c.lock.Lock()
+		delete(c.active, address.MAC)
+		c.lastAttempt[address.MAC] = time.Now()
+		c.lock.Unlock()
+	}()
+
+	device, err := c.adapter.Connect(address, c.options.Params)
+	if err != nil {
+		c.failed(address, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.ResolveTimeout)
+	err = device.waitServicesResolved(ctx)
+	cancel()
+	if err != nil {
+		device.Disconnect()
+		c.failed(address, err)
+		return
+	}
+
+	if c.options.Ready != nil {
+		go c.options.Ready(device)
+	}
+	<-device.Disconnected()
+}
+
+// failed reports a failed connection attempt.
+func (c *AutoConnector) failed(address Address, err error) {
+	if c.options.Failed != nil {
+		c.options.Failed(address, err)
+	}
+}
@@ -0,0 +1,47 @@
+// Package beacon parses and builds iBeacon and Eddystone advertisements.
+//
+// Parse functions take the AdvertisementPayload of a scan result, Decode
+// functions take the raw manufacturer or service data. Every beacon type can
+// be turned into AdvertisementOptions to broadcast it with an Advertisement.
+package beacon
+
+import (
+	"errors"
+
+	"github.com/GKoSon/gobluetooth"
+)
+
+var (
+	errShortData        = errors.New("beacon: data too short")
+	errNotIBeacon       = errors.New("beacon: not an iBeacon")
+	errUnknownFrameType = errors.New("beacon: unknown Eddystone frame type")
+	errTLMVersion       = errors.New("beacon: unsupported Eddystone-TLM version")
+	errInvalidURL       = errors.New("beacon: invalid Eddystone-URL")
+	errURLTooLong       = errors.New("beacon: Eddystone-URL too long")
+)
+
+// Beacon is a parsed iBeacon or Eddystone frame: one of IBeacon,
+// EddystoneUID, EddystoneURL, EddystoneTLM or EddystoneEID.
+type Beacon interface {
+	// AdvertisementOptions returns the options to advertise this beacon.
+	AdvertisementOptions() (bluetooth.AdvertisementOptions, error)
+}
+
+// Parse returns the iBeacon or Eddystone frame in the payload, if any.
+func Parse(payload bluetooth.AdvertisementPayload) (Beacon, bool) {
+	if b, ok := ParseIBeacon(payload); ok {
+		return b, true
+	}
+	return ParseEddystone(payload)
+}
+
+// uuidBytes returns the UUID in big endian byte order, as written in its
+// string form.
+func uuidBytes(uuid bluetooth.UUID) [16]byte {
+	le := uuid.Bytes()
+	var be [16]byte
+	for i := range le {
+		be[i] = le[15-i]
+	}
+	return be
+}
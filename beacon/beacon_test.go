@@ -0,0 +1,146 @@
+package beacon
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GKoSon/gobluetooth"
+)
+
+// payload is an AdvertisementPayload built from advertisement options.
+type payload struct {
+	options bluetooth.AdvertisementOptions
+}
+
+func (p payload) LocalName() string                      { return p.options.LocalName }
+func (p payload) Bytes() []byte                          { return nil }
+func (p payload) ManufacturerData() map[uint16][]byte    { return p.options.ManufacturerData }
+func (p payload) ServiceData() map[bluetooth.UUID][]byte { return p.options.ServiceData }
+func (p payload) TxPower() (int16, bool)                 { return 0, false }
+
+func (p payload) HasServiceUUID(uuid bluetooth.UUID) bool {
+	for _, u := range p.options.ServiceUUIDs {
+		if u == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+func TestIBeacon(t *testing.T) {
+	// Manufacturer data of an iBeacon with UUID
+	// E2C56DB5-DFFB-48D2-B060-D0F5A71096E0, major 1, minor 2 and a measured
+	// power of -59 dBm.
+	data := []byte{
+		0x02, 0x15,
+		0xe2, 0xc5, 0x6d, 0xb5, 0xdf, 0xfb, 0x48, 0xd2, 0xb0, 0x60, 0xd0, 0xf5, 0xa7, 0x10, 0x96, 0xe0,
+		0x00, 0x01,
+		0x00, 0x02,
+		0xc5,
+	}
+	b, err := DecodeIBeacon(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uuid, _ := bluetooth.ParseUUID("e2c56db5-dffb-48d2-b060-d0f5a71096e0")
+	want := IBeacon{UUID: uuid, Major: 1, Minor: 2, MeasuredPower: -59}
+	if b != want {
+		t.Errorf("DecodeIBeacon: expected %+v, got %+v", want, b)
+	}
+	if !bytes.Equal(b.ManufacturerData(), data) {
+		t.Errorf("ManufacturerData: expected %x, got %x", data, b.ManufacturerData())
+	}
+
+	options, _ := b.AdvertisementOptions()
+	parsed, ok := Parse(payload{options})
+	if !ok || parsed != b {
+		t.Errorf("Parse: expected %+v, got %+v", b, parsed)
+	}
+
+	if _, err := DecodeIBeacon(data[:10]); err == nil {
+		t.Error("expected an error for short data")
+	}
+}
+
+func TestEddystone(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		beacon Beacon
+	}{
+		{
+			[]byte{0x00, 0xe7, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 0, 0},
+			EddystoneUID{
+				TxPower:   -25,
+				Namespace: [10]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+				Instance:  [6]byte{11, 12, 13, 14, 15, 16},
+			},
+		},
+		{
+			// "https://" + "goo.gl/S6zT6P"
+			[]byte{0x10, 0xeb, 0x03, 'g', 'o', 'o', '.', 'g', 'l', '/', 'S', '6', 'z', 'T', '6', 'P'},
+			EddystoneURL{TxPower: -21, URL: "https://goo.gl/S6zT6P"},
+		},
+		{
+			// "http://www." + "google" + ".com/" + "maps"
+			[]byte{0x10, 0x00, 0x00, 'g', 'o', 'o', 'g', 'l', 'e', 0x00, 'm', 'a', 'p', 's'},
+			EddystoneURL{TxPower: 0, URL: "http://www.google.com/maps"},
+		},
+		{
+			// 3000 mV, 18.5 °C, 256 advertisements, 360 seconds.
+			[]byte{0x20, 0x00, 0x0b, 0xb8, 0x12, 0x80, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x0e, 0x10},
+			EddystoneTLM{
+				BatteryVoltage:     3000,
+				Temperature:        18.5,
+				AdvertisementCount: 256,
+				Uptime:             360 * time.Second,
+			},
+		},
+		{
+			[]byte{0x30, 0xf6, 0xa1, 0xb2, 0xc3, 0xd4, 0xe5, 0xf6, 0x07, 0x18},
+			EddystoneEID{TxPower: -10, EID: [8]byte{0xa1, 0xb2, 0xc3, 0xd4, 0xe5, 0xf6, 0x07, 0x18}},
+		},
+	}
+	for _, tc := range tests {
+		b, err := DecodeEddystone(tc.data)
+		if err != nil {
+			t.Errorf("DecodeEddystone(%x): %v", tc.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(b, tc.beacon) {
+			t.Errorf("DecodeEddystone(%x): expected %+v, got %+v", tc.data, tc.beacon, b)
+		}
+
+		options, err := tc.beacon.AdvertisementOptions()
+		if err != nil {
+			t.Errorf("AdvertisementOptions(%+v): %v", tc.beacon, err)
+			continue
+		}
+		if !bytes.Equal(options.ServiceData[EddystoneUUID], tc.data) {
+			t.Errorf("AdvertisementOptions(%+v): expected service data %x, got %x", tc.beacon, tc.data, options.ServiceData[EddystoneUUID])
+		}
+		parsed, ok := Parse(payload{options})
+		if !ok || !reflect.DeepEqual(parsed, tc.beacon) {
+			t.Errorf("Parse: expected %+v, got %+v", tc.beacon, parsed)
+		}
+	}
+}
+
+func TestEddystoneURLErrors(t *testing.T) {
+	for _, url := range []string{
+		"ftp://example.com",
+		"https://example.com/a very long path",
+		"https://example.com/this/path/is/too/long",
+	} {
+		if _, err := (EddystoneURL{URL: url}).ServiceData(); err == nil {
+			t.Errorf("expected an error for %q", url)
+		}
+	}
+	if _, err := DecodeEddystone([]byte{0x10, 0x00, 0x04, 'a'}); err == nil {
+		t.Error("expected an error for an invalid scheme")
+	}
+	if _, err := DecodeEddystone([]byte{0x20, 0x01, 0, 0}); err == nil {
+		t.Error("expected an error for an encrypted TLM frame")
+	}
+}
@@ -0,0 +1,280 @@
+package beacon
+
+import (
+	"encoding/binary"
+	"strings"
+	"time"
+
+	"github.com/GKoSon/gobluetooth"
+)
+
+// EddystoneUUID is the 16-bit service UUID under which Eddystone frames are
+// sent as service data.
+var EddystoneUUID = bluetooth.New16BitUUID(0xfeaa)
+
+// Eddystone frame types, the first byte of the service data.
+const (
+	eddystoneUID = 0x00
+	eddystoneURL = 0x10
+	eddystoneTLM = 0x20
+	eddystoneEID = 0x30
+)
+
+// maxEncodedURL is the maximum length of an encoded URL, after the scheme.
+const maxEncodedURL = 17
+
+// EddystoneUID is an Eddystone-UID frame.
+type EddystoneUID struct {
+	// TxPower is the transmit power in dBm at 0 m.
+	TxPower int8
+
+	// Namespace and Instance together form the unique beacon ID.
+	Namespace [10]byte
+	Instance  [6]byte
+}
+
+// EddystoneURL is an Eddystone-URL frame.
+type EddystoneURL struct {
+	// TxPower is the transmit power in dBm at 0 m.
+	TxPower int8
+
+	// URL is the decoded URL, including the scheme.
+	URL string
+}
+
+// EddystoneTLM is an unencrypted Eddystone-TLM telemetry frame.
+type EddystoneTLM struct {
+	// BatteryVoltage is in millivolts, or 0 if not supported.
+	BatteryVoltage uint16
+
+	// Temperature is in degrees Celsius, with a resolution of 1/256 °C.
+	// Beacons without a sensor report -128.
+	Temperature float64
+
+	// AdvertisementCount is the number of advertisements sent since power-on
+	// or reboot.
+	AdvertisementCount uint32
+
+	// Uptime is the time since power-on or reboot, with a resolution of
+	// 100 ms.
+	Uptime time.Duration
+}
+
+// EddystoneEID is an Eddystone-EID frame.
+type EddystoneEID struct {
+	// TxPower is the transmit power in dBm at 0 m.
+	TxPower int8
+
+	// EID is the current ephemeral identifier.
+	EID [8]byte
+}
+
+// ParseEddystone returns the Eddystone frame in the service data of the
+// payload, if any.
+func ParseEddystone(payload bluetooth.AdvertisementPayload) (Beacon, bool) {
+	if payload == nil {
+		return nil, false
+	}
+	data, ok := payload.ServiceData()[EddystoneUUID]
+	if !ok {
+		return nil, false
+	}
+	b, err := DecodeEddystone(data)
+	return b, err == nil
+}
+
+// DecodeEddystone decodes the service data of an Eddystone frame. The result
+// is one of EddystoneUID, EddystoneURL, EddystoneTLM or EddystoneEID.
+func DecodeEddystone(data []byte) (Beacon, error) {
+	if len(data) < 2 {
+		return nil, errShortData
+	}
+	switch data[0] {
+	case eddystoneUID:
+		// The two reserved bytes at the end are optional.
+		if len(data) < 18 {
+			return nil, errShortData
+		}
+		b := EddystoneUID{TxPower: int8(data[1])}
+		copy(b.Namespace[:], data[2:12])
+		copy(b.Instance[:], data[12:18])
+		return b, nil
+	case eddystoneURL:
+		if len(data) < 3 {
+			return nil, errShortData
+		}
+		url, err := decodeURL(data[2], data[3:])
+		if err != nil {
+			return nil, err
+		}
+		return EddystoneURL{TxPower: int8(data[1]), URL: url}, nil
+	case eddystoneTLM:
+		if data[1] != 0x00 {
+			return nil, errTLMVersion
+		}
+		if len(data) < 14 {
+			return nil, errShortData
+		}
+		return EddystoneTLM{
+			BatteryVoltage:     binary.BigEndian.Uint16(data[2:4]),
+			Temperature:        float64(int16(binary.BigEndian.Uint16(data[4:6]))) / 256,
+			AdvertisementCount: binary.BigEndian.Uint32(data[6:10]),
+			Uptime:             time.Duration(binary.BigEndian.Uint32(data[10:14])) * 100 * time.Millisecond,
+		}, nil
+	case eddystoneEID:
+		if len(data) < 10 {
+			return nil, errShortData
+		}
+		b := EddystoneEID{TxPower: int8(data[1])}
+		copy(b.EID[:], data[2:10])
+		return b, nil
+	}
+	return nil, errUnknownFrameType
+}
+
+// ServiceData returns the service data of this frame.
+func (b EddystoneUID) ServiceData() []byte {
+	data := make([]byte, 20)
+	data[0] = eddystoneUID
+	data[1] = byte(b.TxPower)
+	copy(data[2:12], b.Namespace[:])
+	copy(data[12:18], b.Instance[:])
+	return data
+}
+
+// AdvertisementOptions returns the options to advertise this frame.
+func (b EddystoneUID) AdvertisementOptions() (bluetooth.AdvertisementOptions, error) {
+	return eddystoneOptions(b.ServiceData()), nil
+}
+
+// ServiceData returns the service data of this frame. It returns an error if
+// the URL cannot be encoded.
+func (b EddystoneURL) ServiceData() ([]byte, error) {
+	scheme, encoded, err := encodeURL(b.URL)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{eddystoneURL, byte(b.TxPower), scheme}, encoded...), nil
+}
+
+// AdvertisementOptions returns the options to advertise this frame.
+func (b EddystoneURL) AdvertisementOptions() (bluetooth.AdvertisementOptions, error) {
+	data, err := b.ServiceData()
+	if err != nil {
+		return bluetooth.AdvertisementOptions{}, err
+	}
+	return eddystoneOptions(data), nil
+}
+
+// ServiceData returns the service data of this frame.
+func (b EddystoneTLM) ServiceData() []byte {
+	data := make([]byte, 14)
+	data[0] = eddystoneTLM
+	data[1] = 0x00 // unencrypted
+	binary.BigEndian.PutUint16(data[2:4], b.BatteryVoltage)
+	binary.BigEndian.PutUint16(data[4:6], uint16(int16(b.Temperature*256)))
+	binary.BigEndian.PutUint32(data[6:10], b.AdvertisementCount)
+	binary.BigEndian.PutUint32(data[10:14], uint32(b.Uptime/(100*time.Millisecond)))
+	return data
+}
+
+// AdvertisementOptions returns the options to advertise this frame.
+func (b EddystoneTLM) AdvertisementOptions() (bluetooth.AdvertisementOptions, error) {
+	return eddystoneOptions(b.ServiceData()), nil
+}
+
+// ServiceData returns the service data of this frame.
+func (b EddystoneEID) ServiceData() []byte {
+	data := make([]byte, 10)
+	data[0] = eddystoneEID
+	data[1] = byte(b.TxPower)
+	copy(data[2:], b.EID[:])
+	return data
+}
+
+// AdvertisementOptions returns the options to advertise this frame.
+func (b EddystoneEID) AdvertisementOptions() (bluetooth.AdvertisementOptions, error) {
+	return eddystoneOptions(b.ServiceData()), nil
+}
+
+// eddystoneOptions returns the advertisement options for an Eddystone frame.
+// The service UUID must be listed as well as sent as service data.
+func eddystoneOptions(data []byte) bluetooth.AdvertisementOptions {
+	return bluetooth.AdvertisementOptions{
+		ServiceUUIDs: []bluetooth.UUID{EddystoneUUID},
+		ServiceData:  map[bluetooth.UUID][]byte{EddystoneUUID: data},
+	}
+}
+
+// urlSchemes are the URL scheme prefixes, indexed by their code.
+var urlSchemes = [...]string{
+	"http://www.",
+	"https://www.",
+	"http://",
+	"https://",
+}
+
+// urlExpansions are the expansions of the URL bytes 0x00 to 0x0d.
+var urlExpansions = [...]string{
+	".com/", ".org/", ".edu/", ".net/", ".info/", ".biz/", ".gov/",
+	".com", ".org", ".edu", ".net", ".info", ".biz", ".gov",
+}
+
+// decodeURL decodes the scheme code and encoded URL of an Eddystone-URL frame.
+func decodeURL(scheme byte, encoded []byte) (string, error) {
+	if int(scheme) >= len(urlSchemes) {
+		return "", errInvalidURL
+	}
+	var sb strings.Builder
+	sb.WriteString(urlSchemes[scheme])
+	for _, c := range encoded {
+		switch {
+		case int(c) < len(urlExpansions):
+			sb.WriteString(urlExpansions[c])
+		case c > 0x20 && c < 0x7f:
+			sb.WriteByte(c)
+		default:
+			return "", errInvalidURL
+		}
+	}
+	return sb.String(), nil
+}
+
+// encodeURL returns the scheme code and encoded URL for an Eddystone-URL
+// frame, using the longest expansion at every position.
+func encodeURL(url string) (byte, []byte, error) {
+	scheme := -1
+	for i, prefix := range urlSchemes {
+		if strings.HasPrefix(url, prefix) && (scheme < 0 || len(prefix) > len(urlSchemes[scheme])) {
+			scheme = i
+		}
+	}
+	if scheme < 0 {
+		return 0, nil, errInvalidURL
+	}
+	rest := url[len(urlSchemes[scheme]):]
+	var encoded []byte
+	for len(rest) != 0 {
+		code := -1
+		for i, expansion := range urlExpansions {
+			if strings.HasPrefix(rest, expansion) && (code < 0 || len(expansion) > len(urlExpansions[code])) {
+				code = i
+			}
+		}
+		if code >= 0 {
+			encoded = append(encoded, byte(code))
+			rest = rest[len(urlExpansions[code]):]
+			continue
+		}
+		c := rest[0]
+		if c <= 0x20 || c >= 0x7f {
+			return 0, nil, errInvalidURL
+		}
+		encoded = append(encoded, c)
+		rest = rest[1:]
+	}
+	if len(encoded) > maxEncodedURL {
+		return 0, nil, errURLTooLong
+	}
+	return byte(scheme), encoded, nil
+}
@@ -0,0 +1,85 @@
+package beacon
+
+import (
+	"encoding/binary"
+
+	"github.com/GKoSon/gobluetooth"
+)
+
+// AppleCompanyID is the company identifier of Apple, under which iBeacon
+// frames are sent as manufacturer data.
+const AppleCompanyID = 0x004c
+
+// iBeacon manufacturer data starts with type 0x02 and length 0x15.
+const (
+	iBeaconType   = 0x02
+	iBeaconLength = 0x15
+)
+
+// IBeacon is an Apple iBeacon frame.
+type IBeacon struct {
+	// UUID identifies the beacons of an organization or deployment.
+	UUID bluetooth.UUID
+
+	// Major and Minor identify a group of beacons and a single beacon.
+	Major uint16
+	Minor uint16
+
+	// MeasuredPower is the RSSI in dBm at a distance of 1 m, used for
+	// distance estimation.
+	MeasuredPower int8
+}
+
+// ParseIBeacon returns the iBeacon frame in the manufacturer data of the
+// payload, if any.
+func ParseIBeacon(payload bluetooth.AdvertisementPayload) (IBeacon, bool) {
+	if payload == nil {
+		return IBeacon{}, false
+	}
+	data, ok := payload.ManufacturerData()[AppleCompanyID]
+	if !ok {
+		return IBeacon{}, false
+	}
+	b, err := DecodeIBeacon(data)
+	return b, err == nil
+}
+
+// DecodeIBeacon decodes the manufacturer data of an iBeacon, without the
+// company identifier.
+func DecodeIBeacon(data []byte) (IBeacon, error) {
+	if len(data) < 2 || data[0] != iBeaconType || data[1] != iBeaconLength {
+		return IBeacon{}, errNotIBeacon
+	}
+	if len(data) < 2+iBeaconLength {
+		return IBeacon{}, errShortData
+	}
+	var uuid [16]byte
+	copy(uuid[:], data[2:18])
+	return IBeacon{
+		UUID:          bluetooth.NewUUID(uuid),
+		Major:         binary.BigEndian.Uint16(data[18:20]),
+		Minor:         binary.BigEndian.Uint16(data[20:22]),
+		MeasuredPower: int8(data[22]),
+	}, nil
+}
+
+// ManufacturerData returns the manufacturer data of this iBeacon, without the
+// company identifier.
+func (b IBeacon) ManufacturerData() []byte {
+	data := make([]byte, 2+iBeaconLength)
+	data[0] = iBeaconType
+	data[1] = iBeaconLength
+	uuid := uuidBytes(b.UUID)
+	copy(data[2:18], uuid[:])
+	binary.BigEndian.PutUint16(data[18:20], b.Major)
+	binary.BigEndian.PutUint16(data[20:22], b.Minor)
+	data[22] = byte(b.MeasuredPower)
+	return data
+}
+
+// AdvertisementOptions returns the options to advertise this iBeacon.
+func (b IBeacon) AdvertisementOptions() (bluetooth.AdvertisementOptions, error) {
+	return bluetooth.AdvertisementOptions{
+		ManufacturerData: map[uint16][]byte{AppleCompanyID: b.ManufacturerData()},
+	}, nil
+}
@@ -0,0 +1,162 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"errors"
+	"log"
+
+	"github.com/muka/go-bluetooth/bluez/profile/adapter"
+	"github.com/muka/go-bluetooth/bluez/profile/device"
+)
+
+var errNoAdapter = errors.New("bluetooth: device has no adapter")
+
+// BondInfo describes the pairing state of a device known to the adapter.
+type BondInfo struct {
+	// Address of the device. IsRandom is set when BlueZ reports a random
+	// address type.
+	Address Address
+
+	// AddressType is the address type as reported by BlueZ, either "public"
+	// or "random".
+	AddressType string
+
+	// Name is the remote name of the device, if known.
+	Name string
+
+	// Paired is true when pairing with the device has completed.
+	Paired bool
+
+	// Trusted is true when the device is allowed to connect without
+	// authorization.
+	Trusted bool
+
+	// Blocked is true when all incoming connections from the device are
+	// rejected.
+	Blocked bool
+
+	// Bonded is true when the keys from pairing are stored permanently. Older
+	// versions of BlueZ do not have this property, in which case Bonded
+	// follows Paired.
+	Bonded bool
+}
+
+// makeBondInfo creates a BondInfo from a Device1 object.
+func makeBondInfo(dev *device.Device1) BondInfo {
+	props := dev.Properties
+	addr, _ := ParseMAC(props.Address)
+	a := Address{MACAddress{MAC: addr}}
+	a.SetRandom(props.AddressType == "random")
+	return BondInfo{
+		Address:     a,
+		AddressType: props.AddressType,
+		Name:        props.Name,
+		Paired:      props.Paired,
+		Trusted:     props.Trusted,
+		Blocked:     props.Blocked,
+		Bonded:      isBonded(dev),
+	}
+}
+
+// isBonded returns whether the keys of the given device are stored
+// permanently. The Bonded property was added in BlueZ 5.66, fall back to
+// Paired when it does not exist.
+func isBonded(dev *device.Device1) bool {
+	v, err := dev.GetProperty("Bonded")
+	if err != nil {
+		return dev.Properties.Paired
+	}
+	bonded, ok := v.Value().(bool)
+	if !ok {
+		return dev.Properties.Paired
+	}
+	return bonded
+}
+
+// isProtected returns whether the device must be kept in the BlueZ device
+// cache because it is connected or has pairing keys or settings that would
+// be lost.
+func isProtected(dev *device.Device1) bool {
+	// Only ask for Bonded, a D-Bus call, when it matters.
+	return !isEvictable(dev.Properties, false) || isBonded(dev)
+}
+
+// BondedDevices returns the pairing state of all devices that are paired or
+// bonded with this adapter.
+func (a *Adapter) BondedDevices() ([]BondInfo, error) {
+	devices, err := a.proxy().GetDevices()
+	if err != nil {
+		return nil, err
+	}
+	var bonds []BondInfo
+	for _, dev := range devices {
+		info := makeBondInfo(dev)
+		if info.Paired || info.Bonded {
+			bonds = append(bonds, info)
+		}
+	}
+	return bonds, nil
+}
+
+// FlushUnbonded removes stale entries from the BlueZ device cache. Unlike
+// Flush, it keeps devices that are bonded, paired, trusted, blocked or
+// connected so their keys and settings survive.
+func (a *Adapter) FlushUnbonded() error {
+	devices, err := a.proxy().GetDevices()
+	if err != nil {
+		return err
+	}
+	for _, dev := range devices {
+		if isProtected(dev) {
+			continue
+		}
+		err = a.proxy().RemoveDevice(dev.Path())
+		if err != nil {
+			log.Printf("TingGo FlushUnbonded %s fail %v\r\n", dev.Path(), err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Unpair removes the pairing keys of this device. BlueZ can only do this by
+// removing the device from the adapter, so the Device must not be used
+// afterwards.
+func (d *Device) Unpair() error {
+	adapter1, err := d.adapter1()
+	if err != nil {
+		return err
+	}
+	return adapter1.RemoveDevice(d.device.Path())
+}
+
+// SetTrusted marks the device as trusted or untrusted. Trusted devices may
+// connect without authorization.
+func (d *Device) SetTrusted(trusted bool) error {
+	return d.device.SetTrusted(trusted)
+}
+
+// SetBlocked blocks or unblocks the device. Incoming connections from a
+// blocked device are rejected and existing connections are dropped.
+func (d *Device) SetBlocked(blocked bool) error {
+	return d.device.SetBlocked(blocked)
+}
+
+// adapter1 returns the BlueZ adapter object this device belongs to.
+func (d *Device) adapter1() (*adapter.Adapter1, error) {
+	if d.adapter != nil {
+		if proxy := d.adapter.proxy(); proxy != nil {
+			return proxy, nil
+		}
+	}
+	adapter1, err := adapter.GetAdapterFromDevicePath(d.device.Path())
+	if err != nil {
+		return nil, err
+	}
+	if adapter1 == nil {
+		return nil, errNoAdapter
+	}
+	return adapter1, nil
+}
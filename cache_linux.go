@@ -0,0 +1,323 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/muka/go-bluetooth/bluez/profile/device"
+)
+
+var errEvictionRunning = errors.New("bluetooth: cache eviction already running")
+
+// defaultEvictionInterval is how often the device cache is checked when
+// CacheEvictionPolicy.Interval is not set.
+const defaultEvictionInterval = time.Minute
+
+// EvictionReason tells why a device was removed from the BlueZ device cache.
+type EvictionReason uint8
+
+const (
+	// EvictedStale means the device has not been seen for longer than
+	// CacheEvictionPolicy.MaxAge.
+	EvictedStale EvictionReason = iota + 1
+
+	// EvictedOverflow means the cache held more than
+	// CacheEvictionPolicy.MaxDevices devices and this one was the least
+	// recently seen.
+	EvictedOverflow
+)
+
+// String returns a human-readable version of the reason.
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictedStale:
+		return "stale"
+	case EvictedOverflow:
+		return "overflow"
+	}
+	return "unknown"
+}
+
+// EvictedDevice describes a device that was removed from the device cache.
+type EvictedDevice struct {
+	Address  Address
+	Name     string
+	LastSeen time.Time
+	Reason   EvictionReason
+}
+
+// CacheEvictionPolicy configures the background eviction of the BlueZ device
+// cache. Devices that are connected, bonded, paired, trusted or blocked are
+// never evicted, but they do count towards MaxDevices.
+type CacheEvictionPolicy struct {
+	// MaxAge removes devices that have not been seen for this long. Zero
+	// disables removal by age.
+	MaxAge time.Duration
+
+	// MaxDevices caps the number of devices in the cache. When there are
+	// more, the least recently seen devices are removed. Zero disables the
+	// cap.
+	MaxDevices int
+
+	// Interval is how often the cache is checked. It defaults to one minute.
+	Interval time.Duration
+
+	// Evicted is called after every check that removed at least one device.
+	// It is called from the eviction goroutine.
+	Evicted func([]EvictedDevice)
+}
+
+// cacheEvictor tracks when each device was last seen and periodically
+// removes devices according to a CacheEvictionPolicy.
+type cacheEvictor struct {
+	adapter  *Adapter
+	policy   CacheEvictionPolicy
+	lock     sync.Mutex
+	lastSeen map[dbus.ObjectPath]time.Time
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// StartCacheEviction starts removing devices from the BlueZ device cache in
+// the background according to the policy. It replaces Flush, which removes
+// every device that is not connected, including bonded ones. Call
+// StopCacheEviction to stop it.
+//
+// BlueZ does not report when a device was last seen, so this is tracked from
+// the RSSI and advertisement data updates of each device. Devices already in
+// the cache are treated as seen when eviction starts.
+func (a *Adapter) StartCacheEviction(policy CacheEvictionPolicy) error {
+	if policy.Interval <= 0 {
+		policy.Interval = defaultEvictionInterval
+	}
+
+	a.evictionLock.Lock()
+	defer a.evictionLock.Unlock()
+	if a.eviction != nil {
+		return errEvictionRunning
+	}
+
+	e := &cacheEvictor{
+		adapter:  a,
+		policy:   policy,
+		lastSeen: make(map[dbus.ObjectPath]time.Time),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	path := a.proxy().Path()
+	changed, cancelChanged, err := watchSignals(signalFilter{
+		Path:      path,
+		Namespace: true,
+		Interface: "org.freedesktop.DBus.Properties",
+		Member:    "PropertiesChanged",
+	})
+	if err != nil {
+		return err
+	}
+	added, cancelAdded, err := watchSignals(signalFilter{
+		Path:      "/",
+		Interface: "org.freedesktop.DBus.ObjectManager",
+		Member:    "InterfacesAdded",
+		Arg0Path:  path,
+	})
+	if err != nil {
+		cancelChanged()
+		return err
+	}
+
+	devices, err := a.proxy().GetDevices()
+	if err != nil {
+		cancelChanged()
+		cancelAdded()
+		return err
+	}
+	now := time.Now()
+	for _, dev := range devices {
+		e.lastSeen[dev.Path()] = now
+	}
+
+	a.eviction = e
+	go e.run(changed, added, func() {
+		cancelChanged()
+		cancelAdded()
+	})
+	return nil
+}
+
+// StopCacheEviction stops the background eviction started with
+// StartCacheEviction and waits for it to finish.
+func (a *Adapter) StopCacheEviction() {
+	a.evictionLock.Lock()
+	e := a.eviction
+	a.eviction = nil
+	a.evictionLock.Unlock()
+	if e == nil {
+		return
+	}
+	close(e.stop)
+	<-e.done
+}
+
+// run tracks when devices are seen and checks the cache every interval.
+func (e *cacheEvictor) run(changed, added <-chan *dbus.Signal, cancel func()) {
+	defer close(e.done)
+	defer cancel()
+
+	prefix := string(e.adapter.proxy().Path()) + "/"
+	ticker := time.NewTicker(e.policy.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case sig := <-changed:
+			if !strings.HasPrefix(string(sig.Path), prefix) {
+				continue
+			}
+			changes, ok := propertiesChangedFor(sig, sig.Path, "org.bluez.Device1")
+			if !ok {
+				continue
+			}
+			// These properties are updated by BlueZ when an advertisement
+			// is received.
+			_, rssi := changes["RSSI"]
+			_, manufacturerData := changes["ManufacturerData"]
+			_, serviceData := changes["ServiceData"]
+			if rssi || manufacturerData || serviceData {
+				e.seen(sig.Path)
+			}
+		case sig := <-added:
+			if sig.Name != "org.freedesktop.DBus.ObjectManager.InterfacesAdded" || len(sig.Body) < 1 {
+				continue
+			}
+			path, ok := sig.Body[0].(dbus.ObjectPath)
+			if ok && strings.HasPrefix(string(path), prefix) {
+				e.seen(path)
+			}
+		case <-ticker.C:
+			evicted := e.evict()
+			if len(evicted) != 0 && e.policy.Evicted != nil {
+				e.policy.Evicted(evicted)
+			}
+		}
+	}
+}
+
+// seen records that the device with the given object path was seen now.
+func (e *cacheEvictor) seen(path dbus.ObjectPath) {
+	e.lock.Lock()
+	e.lastSeen[path] = time.Now()
+	e.lock.Unlock()
+}
+
+// evict removes the devices that are too old or exceed the cap, and returns
+// what was removed.
+func (e *cacheEvictor) evict() []EvictedDevice {
+	devices, err := e.adapter.proxy().GetDevices()
+	if err != nil {
+		log.Println("TinyGo cache eviction GetDevices", err)
+		return nil
+	}
+
+	now := time.Now()
+	var candidates []evictionCandidate
+	present := make(map[dbus.ObjectPath]struct{}, len(devices))
+	e.lock.Lock()
+	for _, dev := range devices {
+		path := dev.Path()
+		present[path] = struct{}{}
+		lastSeen, ok := e.lastSeen[path]
+		if !ok {
+			lastSeen = now
+			e.lastSeen[path] = now
+		}
+		candidates = append(candidates, evictionCandidate{
+			dev:       dev,
+			lastSeen:  lastSeen,
+			protected: isProtected(dev),
+		})
+	}
+	// Forget devices that were removed by someone else.
+	for path := range e.lastSeen {
+		if _, ok := present[path]; !ok {
+			delete(e.lastSeen, path)
+		}
+	}
+	e.lock.Unlock()
+
+	var evicted []EvictedDevice
+	for _, c := range planEvictions(candidates, e.policy, now) {
+		err := e.adapter.proxy().RemoveDevice(c.dev.Path())
+		if err != nil {
+			// Retried at the next check.
+			log.Printf("TingGo cache eviction %s fail %v\r\n", c.dev.Path(), err)
+			continue
+		}
+		e.lock.Lock()
+		delete(e.lastSeen, c.dev.Path())
+		e.lock.Unlock()
+
+		info := makeBondInfo(c.dev)
+		evicted = append(evicted, EvictedDevice{
+			Address:  info.Address,
+			Name:     info.Name,
+			LastSeen: c.lastSeen,
+			Reason:   c.reason,
+		})
+	}
+	return evicted
+}
+
+// evictionCandidate is a device in the cache.
+type evictionCandidate struct {
+	dev       *device.Device1
+	lastSeen  time.Time
+	protected bool
+	reason    EvictionReason // set by planEvictions
+}
+
+// isEvictable returns whether a device with the given properties may be
+// removed from the cache.
+func isEvictable(props *device.Device1Properties, bonded bool) bool {
+	return !props.Connected && !props.Paired && !props.Trusted && !props.Blocked && !bonded
+}
+
+// planEvictions returns the devices to remove according to the policy, least
+// recently seen first, with the reason for each. Protected devices are never
+// returned but count towards MaxDevices.
+func planEvictions(candidates []evictionCandidate, policy CacheEvictionPolicy, now time.Time) []evictionCandidate {
+	var unprotected []evictionCandidate
+	for _, c := range candidates {
+		if !c.protected {
+			unprotected = append(unprotected, c)
+		}
+	}
+	sort.Slice(unprotected, func(i, j int) bool {
+		return unprotected[i].lastSeen.Before(unprotected[j].lastSeen)
+	})
+
+	var planned []evictionCandidate
+	remaining := len(candidates)
+	for _, c := range unprotected {
+		if policy.MaxAge > 0 && now.Sub(c.lastSeen) > policy.MaxAge {
+			c.reason = EvictedStale
+		} else if policy.MaxDevices > 0 && remaining > policy.MaxDevices {
+			c.reason = EvictedOverflow
+		} else {
+			// Candidates are sorted, so the rest is newer.
+			break
+		}
+		remaining--
+		planned = append(planned, c)
+	}
+	return planned
+}
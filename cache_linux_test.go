@@ -0,0 +1,77 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/muka/go-bluetooth/bluez/profile/device"
+)
+
+func TestIsEvictable(t *testing.T) {
+	tests := []struct {
+		props     *device.Device1Properties
+		bonded    bool
+		evictable bool
+	}{
+		{&device.Device1Properties{}, false, true},
+		{&device.Device1Properties{Trusted: true}, false, false},
+		{&device.Device1Properties{Connected: true}, false, false},
+		{&device.Device1Properties{Paired: true}, false, false},
+		{&device.Device1Properties{Blocked: true}, false, false},
+		{&device.Device1Properties{Trusted: true}, true, false},
+	}
+	for _, tc := range tests {
+		if evictable := isEvictable(tc.props, tc.bonded); evictable != tc.evictable {
+			p := tc.props
+			t.Errorf("connected=%v paired=%v trusted=%v blocked=%v bonded=%v: expected %v, got %v",
+				p.Connected, p.Paired, p.Trusted, p.Blocked, tc.bonded, tc.evictable, evictable)
+		}
+	}
+}
+
+func TestPlanEvictions(t *testing.T) {
+	now := time.Now()
+	seen := func(ago time.Duration, protected bool) evictionCandidate {
+		return evictionCandidate{lastSeen: now.Add(-ago), protected: protected}
+	}
+	candidates := []evictionCandidate{
+		seen(time.Second, false),
+		seen(time.Hour, true), // old but protected
+		seen(10*time.Minute, false),
+		seen(time.Minute, false),
+		seen(2*time.Hour, false),
+	}
+
+	tests := []struct {
+		policy   CacheEvictionPolicy
+		expected []EvictionReason
+		ages     []time.Duration
+	}{
+		// Nothing configured, nothing evicted.
+		{CacheEvictionPolicy{}, nil, nil},
+		// Only the stale devices that are not protected.
+		{CacheEvictionPolicy{MaxAge: 5 * time.Minute}, []EvictionReason{EvictedStale, EvictedStale}, []time.Duration{2 * time.Hour, 10 * time.Minute}},
+		// Protected devices count towards the cap but are kept.
+		{CacheEvictionPolicy{MaxDevices: 2}, []EvictionReason{EvictedOverflow, EvictedOverflow, EvictedOverflow}, []time.Duration{2 * time.Hour, 10 * time.Minute, time.Minute}},
+		// Both: stale first, then overflow.
+		{CacheEvictionPolicy{MaxAge: 30 * time.Minute, MaxDevices: 3}, []EvictionReason{EvictedStale, EvictedOverflow}, []time.Duration{2 * time.Hour, 10 * time.Minute}},
+	}
+	for _, tc := range tests {
+		planned := planEvictions(candidates, tc.policy, now)
+		if len(planned) != len(tc.expected) {
+			t.Errorf("%+v: expected %d evictions, got %d", tc.policy, len(tc.expected), len(planned))
+			continue
+		}
+		for i, c := range planned {
+			if c.reason != tc.expected[i] || now.Sub(c.lastSeen) != tc.ages[i] {
+				t.Errorf("%+v: eviction %d: expected %s seen %s ago, got %s seen %s ago", tc.policy, i, tc.expected[i], tc.ages[i], c.reason, now.Sub(c.lastSeen))
+			}
+			if c.protected {
+				t.Errorf("%+v: protected device planned for eviction", tc.policy)
+			}
+		}
+	}
+}
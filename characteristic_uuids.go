@@ -1,5 +1,5 @@
 // Code generated by bin/gen-characteristic-uuids; DO NOT EDIT.
-// This file was generated on 2021-02-01 14:19:13.563222488 +0100 CET m=+0.007308013 using the list of standard characteristics UUIDs from
+// This file was generated on 2026-10-19 11:10:49.388703362 +0000 UTC m=+0.001831734 using the list of standard characteristics UUIDs from
 // https://github.com/NordicSemiconductor/bluetooth-numbers-database/blob/master/v1/characteristics_uuids.json
 //
 package bluetooth
@@ -973,3 +973,329 @@ var (
 	CharacteristicUUIDMeshProxyDataOut = New16BitUUID(0x2ADE)
 
 )
+
+// characteristicUUIDNames maps the standard characteristic UUIDs to their names.
+var characteristicUUIDNames = map[UUID]string{
+	CharacteristicUUIDAerobicHeartRateLowerLimit: "Aerobic Heart Rate Lower Limit",
+	CharacteristicUUIDAerobicHeartRateUpperLimit: "Aerobic Heart Rate Upper Limit",
+	CharacteristicUUIDAerobicThreshold: "Aerobic Threshold",
+	CharacteristicUUIDAge: "Age",
+	CharacteristicUUIDAggregate: "Aggregate",
+	CharacteristicUUIDAlertCategoryID: "Alert Category ID",
+	CharacteristicUUIDAlertCategoryIDBitMask: "Alert Category ID Bit Mask",
+	CharacteristicUUIDAlertLevel: "Alert Level",
+	CharacteristicUUIDAlertNotificationControlPoint: "Alert Notification Control Point",
+	CharacteristicUUIDAlertStatus: "Alert Status",
+	CharacteristicUUIDAltitude: "Altitude",
+	CharacteristicUUIDAnaerobicHeartRateLowerLimit: "Anaerobic Heart Rate Lower Limit",
+	CharacteristicUUIDAnaerobicHeartRateUpperLimit: "Anaerobic Heart Rate Upper Limit",
+	CharacteristicUUIDAnaerobicThreshold: "Anaerobic Threshold",
+	CharacteristicUUIDAnalog: "Analog",
+	CharacteristicUUIDAnalogOutput: "Analog Output",
+	CharacteristicUUIDApparentWindDirection: "Apparent Wind Direction",
+	CharacteristicUUIDApparentWindSpeed: "Apparent Wind Speed",
+	CharacteristicUUIDAppearance: "Appearance",
+	CharacteristicUUIDBarometricPressureTrend: "Barometric Pressure Trend",
+	CharacteristicUUIDBatteryLevel: "Battery Level",
+	CharacteristicUUIDBatteryLevelState: "Battery Level State",
+	CharacteristicUUIDBatteryPowerState: "Battery Power State",
+	CharacteristicUUIDBloodPressureFeature: "Blood Pressure Feature",
+	CharacteristicUUIDBloodPressureMeasurement: "Blood Pressure Measurement",
+	CharacteristicUUIDBodyCompositionFeature: "Body Composition Feature",
+	CharacteristicUUIDBodyCompositionMeasurement: "Body Composition Measurement",
+	CharacteristicUUIDBodySensorLocation: "Body Sensor Location",
+	CharacteristicUUIDBondManagementControlPoint: "Bond Management Control Point",
+	CharacteristicUUIDBondManagementFeatures: "Bond Management Features",
+	CharacteristicUUIDBootKeyboardInputReport: "Boot Keyboard Input Report",
+	CharacteristicUUIDBootKeyboardOutputReport: "Boot Keyboard Output Report",
+	CharacteristicUUIDBootMouseInputReport: "Boot Mouse Input Report",
+	CharacteristicUUIDCentralAddressResolution: "Central Address Resolution",
+	CharacteristicUUIDCGMFeature: "CGM Feature",
+	CharacteristicUUIDCGMMeasurement: "CGM Measurement",
+	CharacteristicUUIDCGMSessionRunTime: "CGM Session Run Time",
+	CharacteristicUUIDCGMSessionStartTime: "CGM Session Start Time",
+	CharacteristicUUIDCGMSpecificOpsControlPoint: "CGM Specific Ops Control Point",
+	CharacteristicUUIDCGMStatus: "CGM Status",
+	CharacteristicUUIDCrossTrainerData: "Cross Trainer Data",
+	CharacteristicUUIDCSCFeature: "CSC Feature",
+	CharacteristicUUIDCSCMeasurement: "CSC Measurement",
+	CharacteristicUUIDCurrentTime: "Current Time",
+	CharacteristicUUIDCyclingPowerControlPoint: "Cycling Power Control Point",
+	CharacteristicUUIDCyclingPowerFeature: "Cycling Power Feature",
+	CharacteristicUUIDCyclingPowerMeasurement: "Cycling Power Measurement",
+	CharacteristicUUIDCyclingPowerVector: "Cycling Power Vector",
+	CharacteristicUUIDDatabaseChangeIncrement: "Database Change Increment",
+	CharacteristicUUIDDateOfBirth: "Date of Birth",
+	CharacteristicUUIDDateOfThresholdAssessment: "Date of Threshold Assessment",
+	CharacteristicUUIDDateTime: "Date Time",
+	CharacteristicUUIDDateUTC: "Date UTC",
+	CharacteristicUUIDDayDateTime: "Day Date Time",
+	CharacteristicUUIDDayOfWeek: "Day of Week",
+	CharacteristicUUIDDescriptorValueChanged: "Descriptor Value Changed",
+	CharacteristicUUIDDeviceName: "Device Name",
+	CharacteristicUUIDDewPoint: "Dew Point",
+	CharacteristicUUIDDigital: "Digital",
+	CharacteristicUUIDDigitalOutput: "Digital Output",
+	CharacteristicUUIDDSTOffset: "DST Offset",
+	CharacteristicUUIDElevation: "Elevation",
+	CharacteristicUUIDEmailAddress: "Email Address",
+	CharacteristicUUIDExactTime100: "Exact Time 100",
+	CharacteristicUUIDExactTime256: "Exact Time 256",
+	CharacteristicUUIDFatBurnHeartRateLowerLimit: "Fat Burn Heart Rate Lower Limit",
+	CharacteristicUUIDFatBurnHeartRateUpperLimit: "Fat Burn Heart Rate Upper Limit",
+	CharacteristicUUIDFirmwareRevisionString: "Firmware Revision String",
+	CharacteristicUUIDFirstName: "First Name",
+	CharacteristicUUIDFitnessMachineControlPoint: "Fitness Machine Control Point",
+	CharacteristicUUIDFitnessMachineFeature: "Fitness Machine Feature",
+	CharacteristicUUIDFitnessMachineStatus: "Fitness Machine Status",
+	CharacteristicUUIDFiveZoneHeartRateLimits: "Five Zone Heart Rate Limits",
+	CharacteristicUUIDFloorNumber: "Floor Number",
+	CharacteristicUUIDGender: "Gender",
+	CharacteristicUUIDGlucoseFeature: "Glucose Feature",
+	CharacteristicUUIDGlucoseMeasurement: "Glucose Measurement",
+	CharacteristicUUIDGlucoseMeasurementContext: "Glucose Measurement Context",
+	CharacteristicUUIDGustFactor: "Gust Factor",
+	CharacteristicUUIDHardwareRevisionString: "Hardware Revision String",
+	CharacteristicUUIDHeartRateControlPoint: "Heart Rate Control Point",
+	CharacteristicUUIDHeartRateMax: "Heart Rate Max",
+	CharacteristicUUIDHeartRateMeasurement: "Heart Rate Measurement",
+	CharacteristicUUIDHeatIndex: "Heat Index",
+	CharacteristicUUIDHeight: "Height",
+	CharacteristicUUIDHIDControlPoint: "HID Control Point",
+	CharacteristicUUIDHIDInformation: "HID Information",
+	CharacteristicUUIDHipCircumference: "Hip Circumference",
+	CharacteristicUUIDHTTPControlPoint: "HTTP Control Point",
+	CharacteristicUUIDHTTPEntityBody: "HTTP Entity Body",
+	CharacteristicUUIDHTTPHeaders: "HTTP Headers",
+	CharacteristicUUIDHTTPStatusCode: "HTTP Status Code",
+	CharacteristicUUIDHTTPSSecurity: "HTTPS Security",
+	CharacteristicUUIDHumidity: "Humidity",
+	CharacteristicUUIDIDDAnnunciationStatus: "IDD Annunciation Status",
+	CharacteristicUUIDIDDCommandControlPoint: "IDD Command Control Point",
+	CharacteristicUUIDIDDCommandData: "IDD Command Data",
+	CharacteristicUUIDIDDFeatures: "IDD Features",
+	CharacteristicUUIDIDDHistoryData: "IDD History Data",
+	CharacteristicUUIDIDDRecordAccessControlPoint: "IDD Record Access Control Point",
+	CharacteristicUUIDIDDStatus: "IDD Status",
+	CharacteristicUUIDIDDStatusChanged: "IDD Status Changed",
+	CharacteristicUUIDIDDStatusReaderControlPoint: "IDD Status Reader Control Point",
+	CharacteristicUUIDIEEE1107320601RegulatoryCertificationDataList: "IEEE 11073-20601 Regulatory Certification Data List",
+	CharacteristicUUIDIndoorBikeData: "Indoor Bike Data",
+	CharacteristicUUIDIndoorPositioningConfiguration: "Indoor Positioning Configuration",
+	CharacteristicUUIDIntermediateCuffPressure: "Intermediate Cuff Pressure",
+	CharacteristicUUIDIntermediateTemperature: "Intermediate Temperature",
+	CharacteristicUUIDIrradiance: "Irradiance",
+	CharacteristicUUIDLanguage: "Language",
+	CharacteristicUUIDLastName: "Last Name",
+	CharacteristicUUIDLatitude: "Latitude",
+	CharacteristicUUIDLNControlPoint: "LN Control Point",
+	CharacteristicUUIDLNFeature: "LN Feature",
+	CharacteristicUUIDLocalEastCoordinate: "Local East Coordinate",
+	CharacteristicUUIDLocalNorthCoordinate: "Local North Coordinate",
+	CharacteristicUUIDLocalTimeInformation: "Local Time Information",
+	CharacteristicUUIDLocationAndSpeed: "Location and Speed Characteristic",
+	CharacteristicUUIDLocationName: "Location Name",
+	CharacteristicUUIDLongitude: "Longitude",
+	CharacteristicUUIDMagneticDeclination: "Magnetic Declination",
+	CharacteristicUUIDMagneticFluxDensity2D: "Magnetic Flux Density - 2D",
+	CharacteristicUUIDMagneticFluxDensity3D: "Magnetic Flux Density - 3D",
+	CharacteristicUUIDManufacturerNameString: "Manufacturer Name String",
+	CharacteristicUUIDMaximumRecommendedHeartRate: "Maximum Recommended Heart Rate",
+	CharacteristicUUIDMeasurementInterval: "Measurement Interval",
+	CharacteristicUUIDModelNumberString: "Model Number String",
+	CharacteristicUUIDNavigation: "Navigation",
+	CharacteristicUUIDNetworkAvailability: "Network Availability",
+	CharacteristicUUIDNewAler: "New Aler",
+	CharacteristicUUIDObjectActionControlPoint: "Object Action Control Point",
+	CharacteristicUUIDObjectChanged: "Object Changed",
+	CharacteristicUUIDObjectFirstCreated: "Object First-Created",
+	CharacteristicUUIDObjectID: "Object ID",
+	CharacteristicUUIDObjectLastModified: "Object Last-Modified",
+	CharacteristicUUIDObjectListControlPoint: "Object List Control Point",
+	CharacteristicUUIDObjectListFilter: "Object List Filter",
+	CharacteristicUUIDObjectName: "Object Name",
+	CharacteristicUUIDObjectProperties: "Object Properties",
+	CharacteristicUUIDObjectSize: "Object Size",
+	CharacteristicUUIDObjectType: "Object Type",
+	CharacteristicUUIDOTSFeature: "OTS Feature",
+	CharacteristicUUIDPeripheralPreferredConnectionParameters: "Peripheral Preferred Connection Parameters",
+	CharacteristicUUIDPeripheralPrivacyFlag: "Peripheral Privacy Flag",
+	CharacteristicUUIDPLXContinuousMeasurement: "PLX Continuous Measurement Characteristic",
+	CharacteristicUUIDPLXFeatures: "PLX Features",
+	CharacteristicUUIDPLXSpotCheckMeasurement: "PLX Spot-Check Measurement",
+	CharacteristicUUIDPnPID: "PnP ID",
+	CharacteristicUUIDPollenConcentration: "Pollen Concentration",
+	CharacteristicUUIDPosition2D: "Position 2D",
+	CharacteristicUUIDPosition3D: "Position 3D",
+	CharacteristicUUIDPositionQuality: "Position Quality",
+	CharacteristicUUIDPressure: "Pressure",
+	CharacteristicUUIDProtocolMode: "Protocol Mode",
+	CharacteristicUUIDPulseOximetryControlPoint: "Pulse Oximetry Control Point",
+	CharacteristicUUIDRainfall: "Rainfall",
+	CharacteristicUUIDRCFeature: "RC Feature",
+	CharacteristicUUIDRCSettings: "RC Settings",
+	CharacteristicUUIDReconnectionAddress: "Reconnection Address",
+	CharacteristicUUIDReconnectionConfigurationControlPoint: "Reconnection Configuration Control Point",
+	CharacteristicUUIDRecordAccessControlPoint: "Record Access Control Point",
+	CharacteristicUUIDReferenceTimeInformation: "Reference Time Information",
+	CharacteristicUUIDRemovable: "Removable",
+	CharacteristicUUIDReport: "Report",
+	CharacteristicUUIDReportMap: "Report Map",
+	CharacteristicUUIDResolvablePrivateAddressOnly: "Resolvable Private Address Only",
+	CharacteristicUUIDRestingHeartRate: "Resting Heart Rate",
+	CharacteristicUUIDRingerControlPoint: "Ringer Control point",
+	CharacteristicUUIDRingerSetting: "Ringer Setting",
+	CharacteristicUUIDRowerData: "Rower Data",
+	CharacteristicUUIDRSCFeature: "RSC Feature",
+	CharacteristicUUIDRSCMeasurement: "RSC Measurement",
+	CharacteristicUUIDSCControlPoint: "SC Control Point",
+	CharacteristicUUIDScanIntervalWindow: "Scan Interval Window",
+	CharacteristicUUIDScanRefresh: "Scan Refresh",
+	CharacteristicUUIDScientificTemperatureCelsius: "Scientific Temperature Celsius",
+	CharacteristicUUIDSecondaryTimeZone: "Secondary Time Zone",
+	CharacteristicUUIDSensorLocation: "Sensor Location",
+	CharacteristicUUIDSerialNumberString: "Serial Number String",
+	CharacteristicUUIDServiceChanged: "Service Changed",
+	CharacteristicUUIDServiceRequired: "Service Required",
+	CharacteristicUUIDSoftwareRevisionString: "Software Revision String",
+	CharacteristicUUIDSportTypeForAerobicAndAnaerobicThresholds: "Sport Type for Aerobic and Anaerobic Thresholds",
+	CharacteristicUUIDStairClimberData: "Stair Climber Data",
+	CharacteristicUUIDStepClimberData: "Step Climber Data",
+	CharacteristicUUIDString: "String",
+	CharacteristicUUIDSupportedHeartRateRange: "Supported Heart Rate Range",
+	CharacteristicUUIDSupportedInclinationRange: "Supported Inclination Range",
+	CharacteristicUUIDSupportedNewAlertCategory: "Supported New Alert Category",
+	CharacteristicUUIDSupportedPowerRange: "Supported Power Range",
+	CharacteristicUUIDSupportedResistanceLevelRange: "Supported Resistance Level Range",
+	CharacteristicUUIDSupportedSpeedRange: "Supported Speed Range",
+	CharacteristicUUIDSupportedUnreadAlertCategory: "Supported Unread Alert Category",
+	CharacteristicUUIDSystemID: "System ID",
+	CharacteristicUUIDTDSControlPoint: "TDS Control Point",
+	CharacteristicUUIDTemperature: "Temperature",
+	CharacteristicUUIDTemperatureCelsius: "Temperature Celsius",
+	CharacteristicUUIDTemperatureFahrenheit: "Temperature Fahrenheit",
+	CharacteristicUUIDTemperatureMeasurement: "Temperature Measurement",
+	CharacteristicUUIDTemperatureType: "Temperature Type",
+	CharacteristicUUIDThreeZoneHeartRateLimits: "Three Zone Heart Rate Limits",
+	CharacteristicUUIDTimeAccuracy: "Time Accuracy",
+	CharacteristicUUIDTimeBroadcast: "Time Broadcast",
+	CharacteristicUUIDTimeSource: "Time Source",
+	CharacteristicUUIDTimeUpdateControlPoint: "Time Update Control Point",
+	CharacteristicUUIDTimeUpdateState: "Time Update State",
+	CharacteristicUUIDTimeWithDST: "Time with DST",
+	CharacteristicUUIDTimeZone: "Time Zone",
+	CharacteristicUUIDTrainingStatus: "Training Status",
+	CharacteristicUUIDTreadmillData: "Treadmill Data",
+	CharacteristicUUIDTrueWindDirection: "True Wind Direction",
+	CharacteristicUUIDTrueWindSpeed: "True Wind Speed",
+	CharacteristicUUIDTwoZoneHeartRateLimit: "Two Zone Heart Rate Limit",
+	CharacteristicUUIDTxPowerLevel: "Tx Power Level",
+	CharacteristicUUIDUncertainty: "Uncertainty",
+	CharacteristicUUIDUnreadAlertStatus: "Unread Alert Status",
+	CharacteristicUUIDURI: "URI",
+	CharacteristicUUIDUserControlPoint: "User Control Point",
+	CharacteristicUUIDUserIndex: "User Index",
+	CharacteristicUUIDUVIndex: "UV Index",
+	CharacteristicUUIDVO2Max: "VO2 Max",
+	CharacteristicUUIDWaistCircumference: "Waist Circumference",
+	CharacteristicUUIDWeight: "Weight",
+	CharacteristicUUIDWeightMeasurement: "Weight Measurement",
+	CharacteristicUUIDWeightScaleFeature: "Weight Scale Feature",
+	CharacteristicUUIDWindChill: "Wind Chill",
+	CharacteristicUUIDBlinkyButtonState: "Blinky Button State",
+	CharacteristicUUIDBlinkyLEDState: "Blinky LED State",
+	CharacteristicUUIDLegacyDFUControlPoint: "Legacy DFU Control Point",
+	CharacteristicUUIDLegacyDFUPacket: "Legacy DFU Packet",
+	CharacteristicUUIDLegacyDFUVersion: "Legacy DFU Version",
+	CharacteristicUUIDDFUControlPoint: "DFU Control Point",
+	CharacteristicUUIDDFUPacket: "DFU Packet",
+	CharacteristicUUIDButtonlessDFUWithoutBonds: "Buttonless DFU Without Bonds",
+	CharacteristicUUIDButtonlessDFUWithBonds: "Buttonless DFU With Bonds",
+	CharacteristicUUIDExperimentalButtonlessDFU: "Experimental Buttonless DFU",
+	CharacteristicUUIDSMP: "SMP Characteristic",
+	CharacteristicUUIDThingyDeviceName: "Thingy Device Name",
+	CharacteristicUUIDThingyAdvertisingParameters: "Thingy Advertising Parameters",
+	CharacteristicUUIDThingyConnectionParameters: "Thingy Connection Parameters",
+	CharacteristicUUIDThingyEddystoneURL: "Thingy Eddystone URL",
+	CharacteristicUUIDThingyCloudToken: "Thingy Cloud Token",
+	CharacteristicUUIDThingyFWVersion: "Thingy FW Version",
+	CharacteristicUUIDThingyMTURequest: "Thingy MTU Request",
+	CharacteristicUUIDThingyTemperature: "Thingy Temperature",
+	CharacteristicUUIDThingyPressure: "Thingy Pressure",
+	CharacteristicUUIDThingyHumidity: "Thingy Humidity",
+	CharacteristicUUIDThingyAirQuality: "Thingy Air Quality",
+	CharacteristicUUIDThingyColor: "Thingy Color",
+	CharacteristicUUIDThingyConfiguration: "Thingy Configuration",
+	CharacteristicUUIDThingyLEDState: "Thingy LED State",
+	CharacteristicUUIDThingyButtonState: "Thingy Button State",
+	CharacteristicUUIDThingyEXTPin: "Thingy EXT Pin",
+	CharacteristicUUIDThingyMotionConfig: "Thingy Motion Config",
+	CharacteristicUUIDThingyTap: "Thingy Tap",
+	CharacteristicUUIDThingyOrientation: "Thingy Orientation",
+	CharacteristicUUIDThingyQuaternion: "Thingy Quaternion",
+	CharacteristicUUIDThingyPedometer: "Thingy Pedometer",
+	CharacteristicUUIDThingyRawData: "Thingy Raw Data",
+	CharacteristicUUIDThingyEuler: "Thingy Euler",
+	CharacteristicUUIDThingyRotationMatrix: "Thingy Rotation Matrix",
+	CharacteristicUUIDThingyHeading: "Thingy Heading",
+	CharacteristicUUIDThingyGravityVector: "Thingy Gravity Vector",
+	CharacteristicUUIDThingySoundConfig: "Thingy Sound Config",
+	CharacteristicUUIDThingySpeakerData: "Thingy Speaker Data",
+	CharacteristicUUIDThingySpeakerStatus: "Thingy Speaker Status",
+	CharacteristicUUIDThingyMicrophone: "Thingy Microphone",
+	CharacteristicUUIDUARTTX: "UART TX Characteristic",
+	CharacteristicUUIDUARTRX: "UART RX Characteristic",
+	CharacteristicUUIDEddystoneCapabilities: "Eddystone Capabilities",
+	CharacteristicUUIDEddystoneActiveSlot: "Eddystone Active Slot",
+	CharacteristicUUIDEddystoneAdvertisingInterval: "Eddystone Advertising Interval",
+	CharacteristicUUIDEddystoneRadioTxPower: "Eddystone Radio Tx Power",
+	CharacteristicUUIDEddystoneAdvancedAdvertisedTxPower: "Eddystone (Advanced) Advertised Tx Power",
+	CharacteristicUUIDEddystoneLockState: "Eddystone Lock State",
+	CharacteristicUUIDEddystoneUnlock: "Eddystone Unlock",
+	CharacteristicUUIDEddystonePublicECDHKey: "Eddystone Public ECDH Key",
+	CharacteristicUUIDEddystoneEIDIdentityKey: "Eddystone EID Identity Key",
+	CharacteristicUUIDEddystoneADVSlotData: "Eddystone ADV Slot Data",
+	CharacteristicUUIDEddystoneAvancedFactoryReset: "Eddystone Avanced Factory Reset",
+	CharacteristicUUIDEddystoneAdvancedRemainConnectable: "Eddystone (Advanced) Remain Connectable",
+	CharacteristicUUIDFastPairModelID: "Fast Pair Model ID",
+	CharacteristicUUIDFastPairKeybasedPairing: "Fast Pair Key-based Pairing",
+	CharacteristicUUIDFastPairPasskey: "Fast Pair Passkey",
+	CharacteristicUUIDFastPairAccountKey: "Fast Pair Account Key",
+	CharacteristicUUIDFastPairData: "Fast Pair Data",
+	CharacteristicUUIDDeprecatedFastPairModelID: "Deprecated Fast Pair Model ID",
+	CharacteristicUUIDDeprecatedFastPairKeybasedPairing: "Deprecated Fast Pair Key-based Pairing",
+	CharacteristicUUIDDeprecatedFastPairPasskey: "Deprecated Fast Pair Passkey",
+	CharacteristicUUIDDeprecatedFastPairAccountKey: "Deprecated Fast Pair Account Key",
+	CharacteristicUUIDDeprecatedFastPairData: "Deprecated Fast Pair Data",
+	CharacteristicUUIDAppleNotificationSource: "Apple Notification Source",
+	CharacteristicUUIDAppleControlPoint: "Apple Control Point",
+	CharacteristicUUIDAppleDataSource: "Apple Data Source",
+	CharacteristicUUIDAppleRemoteCommand: "Apple Remote Command",
+	CharacteristicUUIDAppleEntityUpdate: "Apple Entity Update",
+	CharacteristicUUIDAppleEntityAttribute: "Apple Entity Attribute",
+	CharacteristicUUIDMicrobitAccelerometerData: "micro:bit Accelerometer Data",
+	CharacteristicUUIDMicrobitAccelerometerPeriod: "micro:bit Accelerometer Period",
+	CharacteristicUUIDMicrobitMagnetometerData: "micro:bit Magnetometer Data",
+	CharacteristicUUIDMicrobitMagnetometerPeriod: "micro:bit Magnetometer Period",
+	CharacteristicUUIDMicrobitMagnetometerBearing: "micro:bit Magnetometer Bearing",
+	CharacteristicUUIDMicrobitButtonAState: "micro:bit Button A State",
+	CharacteristicUUIDMicrobitButtonBState: "micro:bit Button B State",
+	CharacteristicUUIDMicrobitPinData: "micro:bit Pin Data",
+	CharacteristicUUIDMicrobitPinADConfiguration: "micro:bit Pin AD Configuration",
+	CharacteristicUUIDMicrobitPinIOConfiguration: "micro:bit Pin I/O Configuration",
+	CharacteristicUUIDMicrobitPWMControl: "micro:bit PWM Control",
+	CharacteristicUUIDMicrobitLEDMatrixState: "micro:bit LED Matrix State",
+	CharacteristicUUIDMicrobitLEDText: "micro:bit LED Text",
+	CharacteristicUUIDMicrobitScrollingDelay: "micro:bit Scrolling Delay",
+	CharacteristicUUIDMicrobitRequirements: "micro:bit Requirements",
+	CharacteristicUUIDMicrobitEvent: "micro:bit Event",
+	CharacteristicUUIDMicrobitClientRequirements: "micro:bit Client Requirements",
+	CharacteristicUUIDMicrobitClientEvent: "micro:bit Client Event",
+	CharacteristicUUIDMicrobitDFUControl: "micro:bit DFU Control",
+	CharacteristicUUIDMicrobitTemperature: "micro:bit Temperature",
+	CharacteristicUUIDMicrobitTemperaturePeriod: "micro:bit Temperature Period",
+	CharacteristicUUIDMeshProvisioningDataIn: "Mesh Provisioning Data In",
+	CharacteristicUUIDMeshProvisioningDataOut: "Mesh Provisioning Data Out",
+	CharacteristicUUIDMeshProxyDataIn: "Mesh Proxy Data In",
+	CharacteristicUUIDMeshProxyDataOut: "Mesh Proxy Data Out",
+}
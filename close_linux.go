@@ -0,0 +1,178 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"runtime"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/muka/go-bluetooth/bluez"
+)
+
+var errAdapterClosed = errors.New("bluetooth: adapter is closed")
+
+// AdapterStats counts the resources held by an adapter and by this package,
+// for debugging. Numbers that keep growing in a long-running process point
+// to a leak.
+type AdapterStats struct {
+	// Connections is the number of devices that were connected through this
+	// adapter with Connect or MUKAConnect and are still connected.
+	Connections int
+
+	// Notifications is the number of notification subscriptions made with
+	// EnableNotifications on devices of this adapter.
+	Notifications int
+
+	// Scanning, Advertising and CacheEviction report whether a scan, the
+	// default advertisement and the cache eviction are running.
+	Scanning      bool
+	Advertising   bool
+	CacheEviction bool
+
+	// Discovery is the state of the discovery coordinator.
+	Discovery DiscoveryState
+
+	// Subscriptions and MatchRules are the signal subscriptions of this
+	// package and the match rules they added on the system bus. They are
+	// shared by all adapters.
+	Subscriptions int
+	MatchRules    int
+
+	// DroppedSignals is the number of signals that were dropped because a
+	// subscriber, such as a notification callback, did not keep up. It is
+	// shared by all adapters.
+	DroppedSignals uint64
+
+	// Goroutines is the number of goroutines of the process.
+	Goroutines int
+}
+
+// Stats returns the resources currently held by the adapter.
+func (a *Adapter) Stats() AdapterStats {
+	stats := AdapterStats{
+		Scanning:   a.scanning(),
+		Discovery:  a.DiscoveryState(),
+		Goroutines: runtime.NumGoroutine(),
+	}
+	a.connectionsLock.Lock()
+	stats.Connections = len(a.connections)
+	a.connectionsLock.Unlock()
+	stats.Notifications = len(a.notifications())
+	if adv := a.advertisement(); adv != nil {
+		adv.lock.Lock()
+		stats.Advertising = adv.started
+		adv.lock.Unlock()
+	}
+	a.evictionLock.Lock()
+	stats.CacheEviction = a.eviction != nil
+	a.evictionLock.Unlock()
+	stats.Subscriptions, stats.MatchRules, stats.DroppedSignals = router.stats()
+	return stats
+}
+
+// Close releases everything the adapter acquired: it stops the scan, the
+// discovery session and its watcher, clears the discovery filter, stops the
+// default advertisement, the cache eviction and the notifications of its
+// devices, and disconnects the devices that were connected through it. It
+// waits for the disconnects no longer than the context allows and returns
+// the first error, but always releases as much as it can.
+//
+// Supervisors, pools and auto connectors that use the adapter must be stopped
+// before. The adapter can be used again after calling Enable.
+func (a *Adapter) Close(ctx context.Context) error {
+	p := a.proxy()
+	if p == nil {
+		return nil
+	}
+	var firstErr error
+	keep := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	a.StopScan()
+	a.StopCacheEviction()
+	for ch, n := range a.notifications() {
+		err := n.char.DisableNotificationsContext(ctx, ch)
+		if err != nil && n.char.device.IsConnected() {
+			// Notifications end with the connection anyway.
+			keep(err)
+		}
+	}
+	if adv := a.advertisement(); adv != nil {
+		adv.lock.Lock()
+		if adv.unexpose != nil {
+			adv.unexpose()
+			adv.unexpose = nil
+			adv.started = false
+		}
+		adv.lock.Unlock()
+	}
+
+	keep(a.closeDiscovery())
+	keep(p.SetDiscoveryFilter(nil))
+	a.scanLock.Lock()
+	a.scanFilterSet = false
+	a.scanLock.Unlock()
+
+	a.connectionsLock.Lock()
+	devices := make([]*Device, 0, len(a.connections))
+	for _, d := range a.connections {
+		devices = append(devices, d)
+	}
+	a.connectionsLock.Unlock()
+	for _, d := range devices {
+		if err := d.DisconnectContext(ctx); err != nil && err != ErrStackRestarted {
+			keep(err)
+		}
+	}
+
+	untrackAdapter(a)
+	return firstErr
+}
+
+// isClosed returns whether the adapter was closed with Close and not enabled
+// again.
+func (a *Adapter) isClosed() bool {
+	a.discovery.lock.Lock()
+	defer a.discovery.lock.Unlock()
+	return a.discovery.closed
+}
+
+// notifications returns the notification subscriptions on devices of this
+// adapter.
+func (a *Adapter) notifications() map[chan *bluez.PropertyChanged]notification {
+	subs := make(map[chan *bluez.PropertyChanged]notification)
+	notifications.lock.Lock()
+	defer notifications.lock.Unlock()
+	for ch, n := range notifications.subs {
+		if n.char.device != nil && n.char.device.adapter == a {
+			subs[ch] = n
+		}
+	}
+	return subs
+}
+
+// trackConnection records a device that was connected through this adapter,
+// so that Close can disconnect it.
+func (a *Adapter) trackConnection(d *Device) {
+	a.connectionsLock.Lock()
+	defer a.connectionsLock.Unlock()
+	if a.connections == nil {
+		a.connections = make(map[dbus.ObjectPath]*Device)
+	}
+	a.connections[d.device.Path()] = d
+}
+
+// untrackConnection removes a device once it is disconnected.
+func (a *Adapter) untrackConnection(d *Device) {
+	a.connectionsLock.Lock()
+	defer a.connectionsLock.Unlock()
+	if a.connections[d.device.Path()] == d {
+		delete(a.connections, d.device.Path())
+	}
+}
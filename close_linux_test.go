@@ -0,0 +1,103 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"testing"
+
+	"github.com/muka/go-bluetooth/bluez"
+)
+
+func TestAdapterStats(t *testing.T) {
+	a := NewAdapter("hci0")
+	other := NewAdapter("hci1")
+	ours := make(chan *bluez.PropertyChanged)
+	theirs := make(chan *bluez.PropertyChanged)
+	notifications.lock.Lock()
+	notifications.subs[ours] = notification{DeviceCharacteristic{device: &Device{adapter: a}}, func() {}}
+	notifications.subs[theirs] = notification{DeviceCharacteristic{device: &Device{adapter: other}}, func() {}}
+	notifications.lock.Unlock()
+	defer func() {
+		notifications.lock.Lock()
+		delete(notifications.subs, ours)
+		delete(notifications.subs, theirs)
+		notifications.lock.Unlock()
+	}()
+
+	subs := a.notifications()
+	if _, ok := subs[ours]; !ok || len(subs) != 1 {
+		t.Errorf("expected only the notification of the adapter, got %d", len(subs))
+	}
+	stats := a.Stats()
+	if stats.Notifications != 1 || stats.Connections != 0 || stats.Scanning || stats.Advertising {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.Goroutines == 0 {
+		t.Error("no goroutines counted")
+	}
+
+	// Closed adapters do not start discovery until they are enabled again.
+	a.discovery.closed = true
+	if _, err := a.RequestDiscovery(); err != errAdapterClosed {
+		t.Errorf("RequestDiscovery after Close: %v", err)
+	}
+	if state := a.DiscoveryState(); state.Requests != 0 {
+		t.Errorf("request counted after Close: %+v", state)
+	}
+}
+
+func TestAdapterScanState(t *testing.T) {
+	a := NewAdapter("hci0")
+	first, err := a.startScan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.startScan(); err != errScanning {
+		t.Errorf("expected errScanning for a second scan, got %v", err)
+	}
+	if !a.Stats().Scanning {
+		t.Error("expected the adapter to be scanning")
+	}
+
+	// A scan that ends with an error clears the state.
+	a.endScan(first)
+	if a.Stats().Scanning {
+		t.Error("still scanning after the scan ended")
+	}
+
+	// A scan stopped with StopScan does not clear the next scan.
+	second, err := a.startScan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.StopScan()
+	third, err := a.startScan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.endScan(second)
+	if !a.scanning() {
+		t.Error("ending an old scan stopped the current one")
+	}
+	a.endScan(third)
+}
+
+func TestAdapterClosedChan(t *testing.T) {
+	a := NewAdapter("hci0")
+	closed := a.closedChan()
+	select {
+	case <-closed:
+		t.Fatal("closed before Close")
+	default:
+	}
+	a.discovery.lock.Lock()
+	close(a.discovery.done)
+	a.discovery.closed = true
+	a.discovery.lock.Unlock()
+	<-closed
+
+	// The adapter stays closed for late callers.
+	a.discovery.done = nil
+	<-a.closedChan()
+}
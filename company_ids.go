@@ -0,0 +1,46 @@
+// Code generated by bin/gen-company-ids; DO NOT EDIT.
+// This file was generated on 2026-10-19 12:18:13.710988202 +0000 UTC m=+0.000294927 from data/company_ids.json, a curated
+// subset of the company identifiers in
+// https://github.com/NordicSemiconductor/bluetooth-numbers-database/blob/master/v1/company_ids.json
+// Values outside the subset are treated as unknown.
+
+package bluetooth
+
+// companyNames maps the company identifiers assigned by the Bluetooth SIG to
+// the names of the companies.
+var companyNames = map[uint16]string{
+	0x0000: "Ericsson Technology Licensing",
+	0x0001: "Nokia Mobile Phones",
+	0x0002: "Intel Corp.",
+	0x0003: "IBM Corp.",
+	0x0004: "Toshiba Corp.",
+	0x0005: "3Com",
+	0x0006: "Microsoft",
+	0x0007: "Lucent",
+	0x0008: "Motorola",
+	0x0009: "Infineon Technologies AG",
+	0x000A: "Qualcomm Technologies International, Ltd. (QTIL)",
+	0x000B: "Silicon Wave",
+	0x000C: "Digianswer A/S",
+	0x000D: "Texas Instruments Inc.",
+	0x000F: "Broadcom Corporation",
+	0x0013: "Atmel Corporation",
+	0x001D: "Qualcomm",
+	0x0030: "ST Microelectronics",
+	0x0046: "MediaTek, Inc.",
+	0x004C: "Apple, Inc.",
+	0x0057: "Harman International Industries, Inc.",
+	0x0059: "Nordic Semiconductor ASA",
+	0x005D: "Realtek Semiconductor Corporation",
+	0x0075: "Samsung Electronics Co. Ltd.",
+	0x0078: "Nike, Inc.",
+	0x0087: "Garmin International, Inc.",
+	0x009E: "Bose Corporation",
+	0x00C4: "LG Electronics",
+	0x00E0: "Google",
+	0x0157: "Anhui Huami Information Technology Co., Ltd.",
+	0x0171: "Amazon.com Services, LLC.",
+	0x02E5: "Espressif Incorporated",
+	0x038F: "Xiaomi Inc.",
+	0x0499: "Ruuvi Innovations Ltd.",
+}
@@ -0,0 +1,104 @@
+package decoders
+
+import (
+	"github.com/GKoSon/gobluetooth"
+)
+
+// BTHomeUUID is the service UUID under which BTHome v2 devices send their
+// readings.
+var BTHomeUUID = bluetooth.New16BitUUID(0xfcd2)
+
+// bthomeObject describes how a BTHome object is encoded.
+type bthomeObject struct {
+	quantity Quantity // empty for objects that are skipped
+	size     int      // in bytes, little endian
+	signed   bool
+	factor   float64
+	unit     Unit
+}
+
+// bthomeObjects are the BTHome v2 objects by object ID. Binary sensors and
+// events are not decoded, but their size is needed to skip them.
+var bthomeObjects = map[byte]bthomeObject{
+	0x00: {PacketID, 1, false, 1, NoUnit},
+	0x01: {Battery, 1, false, 1, Percent},
+	0x02: {Temperature, 2, true, 0.01, Celsius},
+	0x03: {Humidity, 2, false, 0.01, Percent},
+	0x04: {Pressure, 3, false, 0.01, Hectopascal},
+	0x05: {Illuminance, 3, false, 0.01, Lux},
+	0x06: {Mass, 2, false, 0.01, Kilogram},
+	0x07: {Mass, 2, false, 0.01, Pound},
+	0x08: {DewPoint, 2, true, 0.01, Celsius},
+	0x09: {Count, 1, false, 1, NoUnit},
+	0x0a: {Energy, 3, false, 0.001, KilowattHour},
+	0x0b: {Power, 3, false, 0.01, Watt},
+	0x0c: {Voltage, 2, false, 0.001, Volt},
+	0x0d: {PM25, 2, false, 1, MicrogramPerCubic},
+	0x0e: {PM10, 2, false, 1, MicrogramPerCubic},
+	0x12: {CO2, 2, false, 1, PartsPerMillion},
+	0x13: {VOC, 2, false, 1, MicrogramPerCubic},
+	0x14: {Moisture, 2, false, 0.01, Percent},
+	0x2e: {Humidity, 1, false, 1, Percent},
+	0x2f: {Moisture, 1, false, 1, Percent},
+	0x3a: {"", 1, false, 0, NoUnit}, // button event
+	0x3c: {"", 2, false, 0, NoUnit}, // dimmer event
+	0x3d: {Count, 2, false, 1, NoUnit},
+	0x3e: {Count, 4, false, 1, NoUnit},
+	0x3f: {Rotation, 2, true, 0.1, Degree},
+	0x40: {Distance, 2, false, 1, Millimeter},
+	0x41: {Distance, 2, false, 100, Millimeter},
+	0x43: {Current, 2, false, 0.001, Ampere},
+	0x45: {Temperature, 2, true, 0.1, Celsius},
+	0x4a: {Voltage, 2, false, 0.1, Volt},
+}
+
+// DecodeBTHome decodes unencrypted BTHome v2 service data. Decoding stops at
+// the first object that is not known, returning the measurements before it.
+func DecodeBTHome(data []byte) ([]Measurement, error) {
+	if len(data) < 1 {
+		return nil, errShortData
+	}
+	info := data[0]
+	if info>>5 != 2 {
+		return nil, errUnknownFormat
+	}
+	if info&0x01 != 0 {
+		return nil, errEncrypted
+	}
+	var measurements []Measurement
+	data = data[1:]
+	for len(data) != 0 {
+		id := data[0]
+		object, ok := bthomeObjects[id]
+		if !ok {
+			if id >= 0x0f && id <= 0x2d {
+				// Binary sensors have a single byte.
+				object = bthomeObject{size: 1}
+			} else {
+				// The size of unknown objects is unknown, so the rest cannot
+				// be decoded. Keep what was decoded before, as newer devices
+				// may add objects that this package does not know yet.
+				if len(measurements) == 0 {
+					return nil, errUnknownFormat
+				}
+				return measurements, nil
+			}
+		}
+		if len(data) < 1+object.size {
+			return nil, errShortData
+		}
+		if object.quantity != "" {
+			var v uint32
+			for i := object.size - 1; i >= 0; i-- {
+				v = v<<8 | uint32(data[1+i])
+			}
+			value := float64(v)
+			if object.signed && v&(1<<(object.size*8-1)) != 0 {
+				value -= float64(uint64(1) << (object.size * 8))
+			}
+			measurements = append(measurements, Measurement{object.quantity, value * object.factor, object.unit})
+		}
+		data = data[1+object.size:]
+	}
+	return measurements, nil
+}
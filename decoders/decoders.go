@@ -0,0 +1,244 @@
+// Package decoders decodes sensor readings that vendor devices broadcast in
+// their manufacturer data or service data, such as RuuviTag, Xiaomi
+// thermometers with custom firmware, BTHome v2 and Govee thermometers.
+//
+// Decoders are kept in a Registry keyed by company identifier or service data
+// UUID. The Default registry contains all decoders of this package:
+//
+//	adapter.Scan(nil, func(adapter *bluetooth.Adapter, result bluetooth.ScanResult) {
+//		if reading, ok := decoders.DecodeScanResult(result); ok {
+//			if t, ok := reading.Get(decoders.Temperature); ok {
+//				println(result.Address.String(), t.String())
+//			}
+//		}
+//	})
+package decoders
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/GKoSon/gobluetooth"
+)
+
+var (
+	errShortData     = errors.New("decoders: data too short")
+	errUnknownFormat = errors.New("decoders: unknown data format")
+	errEncrypted     = errors.New("decoders: encrypted data is not supported")
+)
+
+// Quantity is the kind of value a Measurement holds.
+type Quantity string
+
+// Quantities decoded by this package.
+const (
+	Temperature    Quantity = "temperature"
+	Humidity       Quantity = "humidity"
+	Pressure       Quantity = "pressure"
+	Battery        Quantity = "battery"
+	Voltage        Quantity = "voltage"
+	AccelerationX  Quantity = "acceleration_x"
+	AccelerationY  Quantity = "acceleration_y"
+	AccelerationZ  Quantity = "acceleration_z"
+	TxPower        Quantity = "tx_power"
+	MovementCount  Quantity = "movement_count"
+	PacketID       Quantity = "packet_id"
+	Illuminance    Quantity = "illuminance"
+	DewPoint       Quantity = "dew_point"
+	Count          Quantity = "count"
+	Energy         Quantity = "energy"
+	Power          Quantity = "power"
+	Current        Quantity = "current"
+	Mass           Quantity = "mass"
+	PM25           Quantity = "pm2_5"
+	PM10           Quantity = "pm10"
+	CO2            Quantity = "co2"
+	VOC            Quantity = "voc"
+	Moisture       Quantity = "moisture"
+	Distance       Quantity = "distance"
+	Rotation       Quantity = "rotation"
+	SequenceNumber Quantity = "sequence_number"
+)
+
+// Unit is the unit of a Measurement.
+type Unit string
+
+// Units used by this package.
+const (
+	Celsius           Unit = "°C"
+	Percent           Unit = "%"
+	Hectopascal       Unit = "hPa"
+	Volt              Unit = "V"
+	Ampere            Unit = "A"
+	StandardGravity   Unit = "g"
+	DBm               Unit = "dBm"
+	Lux               Unit = "lx"
+	KilowattHour      Unit = "kWh"
+	Watt              Unit = "W"
+	Kilogram          Unit = "kg"
+	Pound             Unit = "lb"
+	MicrogramPerCubic Unit = "µg/m³"
+	PartsPerMillion   Unit = "ppm"
+	Millimeter        Unit = "mm"
+	Degree            Unit = "°"
+	NoUnit            Unit = ""
+)
+
+// Measurement is a single decoded value.
+type Measurement struct {
+	Quantity Quantity
+	Value    float64
+	Unit     Unit
+}
+
+// String returns the value with its unit, for example "21.5 °C".
+func (m Measurement) String() string {
+	s := strconv.FormatFloat(m.Value, 'f', -1, 64)
+	if m.Unit != NoUnit {
+		s += " " + string(m.Unit)
+	}
+	return s
+}
+
+// Reading is the result of decoding an advertisement.
+type Reading struct {
+	// Decoder is the name of the decoder, for example "ruuvi-5".
+	Decoder string
+
+	// Measurements are the decoded values.
+	Measurements []Measurement
+}
+
+// Get returns the first measurement of the given quantity.
+func (r Reading) Get(quantity Quantity) (Measurement, bool) {
+	for _, m := range r.Measurements {
+		if m.Quantity == quantity {
+			return m, true
+		}
+	}
+	return Measurement{}, false
+}
+
+// DecodeFunc decodes manufacturer data (without the company identifier) or
+// service data (without the UUID). It returns an error if the data is not in
+// the format of the decoder, so that the next decoder can be tried.
+type DecodeFunc func(data []byte) ([]Measurement, error)
+
+// decoder is a registered DecodeFunc.
+type decoder struct {
+	name   string
+	decode DecodeFunc
+}
+
+// Registry maps company identifiers and service data UUIDs to decoders. The
+// zero value is an empty registry ready to use.
+type Registry struct {
+	lock         sync.RWMutex
+	manufacturer map[uint16][]decoder
+	service      map[bluetooth.UUID][]decoder
+}
+
+// Default is the registry used by Decode. It contains all decoders of this
+// package.
+var Default = newDefaultRegistry()
+
+// newDefaultRegistry returns a registry with all decoders of this package.
+func newDefaultRegistry() *Registry {
+	r := &Registry{}
+	r.RegisterManufacturer(RuuviCompanyID, "ruuvi-3", DecodeRuuvi3)
+	r.RegisterManufacturer(RuuviCompanyID, "ruuvi-5", DecodeRuuvi5)
+	r.RegisterServiceData(EnvironmentalSensingUUID, "xiaomi-atc", DecodeATC)
+	r.RegisterServiceData(EnvironmentalSensingUUID, "xiaomi-pvvx", DecodePVVX)
+	r.RegisterServiceData(BTHomeUUID, "bthome-v2", DecodeBTHome)
+	r.RegisterManufacturer(GoveeH5075CompanyID, "govee-h5075", DecodeGoveeH5075)
+	r.RegisterManufacturer(GoveeH510xCompanyID, "govee-h510x", DecodeGoveeH510x)
+	return r
+}
+
+// RegisterManufacturer adds a decoder for the manufacturer data of the given
+// company. Decoders for the same company are tried in the order they were
+// registered.
+func (r *Registry) RegisterManufacturer(companyID uint16, name string, decode DecodeFunc) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.manufacturer == nil {
+		r.manufacturer = make(map[uint16][]decoder)
+	}
+	r.manufacturer[companyID] = append(r.manufacturer[companyID], decoder{name, decode})
+}
+
+// RegisterServiceData adds a decoder for the service data of the given
+// service UUID. Decoders for the same UUID are tried in the order they were
+// registered.
+func (r *Registry) RegisterServiceData(uuid bluetooth.UUID, name string, decode DecodeFunc) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.service == nil {
+		r.service = make(map[bluetooth.UUID][]decoder)
+	}
+	r.service[uuid] = append(r.service[uuid], decoder{name, decode})
+}
+
+// Decode decodes the first manufacturer data or service data in the payload
+// for which a decoder is registered and succeeds. Manufacturer data is tried
+// before service data, each in the order of company identifiers and UUIDs,
+// so that the result does not depend on the order of the payload maps.
+func (r *Registry) Decode(payload bluetooth.AdvertisementPayload) (Reading, bool) {
+	if payload == nil {
+		return Reading{}, false
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	manufacturerData := payload.ManufacturerData()
+	companyIDs := make([]uint16, 0, len(manufacturerData))
+	for companyID := range manufacturerData {
+		companyIDs = append(companyIDs, companyID)
+	}
+	sort.Slice(companyIDs, func(i, j int) bool { return companyIDs[i] < companyIDs[j] })
+	for _, companyID := range companyIDs {
+		if reading, ok := decodeWith(r.manufacturer[companyID], manufacturerData[companyID]); ok {
+			return reading, true
+		}
+	}
+	serviceData := payload.ServiceData()
+	uuids := make([]bluetooth.UUID, 0, len(serviceData))
+	for uuid := range serviceData {
+		uuids = append(uuids, uuid)
+	}
+	sort.Slice(uuids, func(i, j int) bool { return uuids[i].String() < uuids[j].String() })
+	for _, uuid := range uuids {
+		if reading, ok := decodeWith(r.service[uuid], serviceData[uuid]); ok {
+			return reading, true
+		}
+	}
+	return Reading{}, false
+}
+
+// DecodeScanResult decodes the advertisement payload of a scan result.
+func (r *Registry) DecodeScanResult(result bluetooth.ScanResult) (Reading, bool) {
+	return r.Decode(result.AdvertisementPayload)
+}
+
+// decodeWith returns the reading of the first decoder that succeeds.
+func decodeWith(decoders []decoder, data []byte) (Reading, bool) {
+	for _, d := range decoders {
+		measurements, err := d.decode(data)
+		if err == nil {
+			return Reading{Decoder: d.name, Measurements: measurements}, true
+		}
+	}
+	return Reading{}, false
+}
+
+// Decode decodes the payload with the Default registry.
+func Decode(payload bluetooth.AdvertisementPayload) (Reading, bool) {
+	return Default.Decode(payload)
+}
+
+// DecodeScanResult decodes the advertisement payload of a scan result with
+// the Default registry.
+func DecodeScanResult(result bluetooth.ScanResult) (Reading, bool) {
+	return Default.DecodeScanResult(result)
+}
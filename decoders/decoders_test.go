@@ -0,0 +1,181 @@
+package decoders
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/GKoSon/gobluetooth"
+)
+
+// payload is an AdvertisementPayload with only manufacturer and service data.
+type payload struct {
+	manufacturerData map[uint16][]byte
+	serviceData      map[bluetooth.UUID][]byte
+}
+
+func (p payload) LocalName() string                      { return "" }
+func (p payload) HasServiceUUID(bluetooth.UUID) bool     { return false }
+func (p payload) Bytes() []byte                          { return nil }
+func (p payload) ManufacturerData() map[uint16][]byte    { return p.manufacturerData }
+func (p payload) ServiceData() map[bluetooth.UUID][]byte { return p.serviceData }
+func (p payload) TxPower() (int16, bool)                 { return 0, false }
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload payload
+		decoder string
+		want    []Measurement
+	}{
+		{
+			"Ruuvi format 3",
+			payload{manufacturerData: map[uint16][]byte{RuuviCompanyID: mustHex("03291a1ece1efc18f94202ca0b53")}},
+			"ruuvi-3",
+			[]Measurement{
+				{Temperature, 26.3, Celsius},
+				{Humidity, 20.5, Percent},
+				{Pressure, 1027.66, Hectopascal},
+				{AccelerationX, -1, StandardGravity},
+				{AccelerationY, -1.726, StandardGravity},
+				{AccelerationZ, 0.714, StandardGravity},
+				{Voltage, 2.899, Volt},
+			},
+		},
+		{
+			"Ruuvi format 5",
+			payload{manufacturerData: map[uint16][]byte{RuuviCompanyID: mustHex("0512fc5394c37c0004fffc040cac364200cdcbb8334c884f")}},
+			"ruuvi-5",
+			[]Measurement{
+				{Temperature, 24.3, Celsius},
+				{Humidity, 53.49, Percent},
+				{Pressure, 1000.44, Hectopascal},
+				{AccelerationX, 0.004, StandardGravity},
+				{AccelerationY, -0.004, StandardGravity},
+				{AccelerationZ, 1.036, StandardGravity},
+				{Voltage, 2.977, Volt},
+				{TxPower, 4, DBm},
+				{MovementCount, 66, NoUnit},
+				{SequenceNumber, 205, NoUnit},
+			},
+		},
+		{
+			"ATC1441",
+			payload{serviceData: map[bluetooth.UUID][]byte{EnvironmentalSensingUUID: mustHex("a4c138112233" + "00e6" + "37" + "5a" + "0b8a" + "17")}},
+			"xiaomi-atc",
+			[]Measurement{
+				{Temperature, 23, Celsius},
+				{Humidity, 55, Percent},
+				{Battery, 90, Percent},
+				{Voltage, 2.954, Volt},
+				{PacketID, 23, NoUnit},
+			},
+		},
+		{
+			"pvvx",
+			payload{serviceData: map[bluetooth.UUID][]byte{EnvironmentalSensingUUID: mustHex("332211" + "38c1a4" + "fd08" + "a715" + "8a0b" + "5a" + "17" + "04")}},
+			"xiaomi-pvvx",
+			[]Measurement{
+				{Temperature, 23.01, Celsius},
+				{Humidity, 55.43, Percent},
+				{Voltage, 2.954, Volt},
+				{Battery, 90, Percent},
+				{PacketID, 23, NoUnit},
+			},
+		},
+		{
+			"BTHome v2",
+			payload{serviceData: map[bluetooth.UUID][]byte{BTHomeUUID: mustHex("4002ca0903bf13" + "1001" + "0161")}},
+			"bthome-v2",
+			[]Measurement{
+				{Temperature, 25.06, Celsius},
+				{Humidity, 50.55, Percent},
+				{Battery, 97, Percent},
+			},
+		},
+		{
+			"BTHome v2 with an unknown object",
+			payload{serviceData: map[bluetooth.UUID][]byte{BTHomeUUID: mustHex("4002ca09" + "42010203" + "0161")}},
+			"bthome-v2",
+			[]Measurement{
+				{Temperature, 25.06, Celsius},
+			},
+		},
+		{
+			"Govee H5075",
+			payload{manufacturerData: map[uint16][]byte{GoveeH5075CompanyID: mustHex("0003519e6400")}},
+			"govee-h5075",
+			[]Measurement{
+				{Temperature, 21.7, Celsius},
+				{Humidity, 50.2, Percent},
+				{Battery, 100, Percent},
+			},
+		},
+		{
+			"Govee H5075 below zero",
+			payload{manufacturerData: map[uint16][]byte{GoveeH5075CompanyID: mustHex("00811c4c4b00")}},
+			"govee-h5075",
+			[]Measurement{
+				{Temperature, -7.2, Celsius},
+				{Humidity, 78, Percent},
+				{Battery, 75, Percent},
+			},
+		},
+	}
+	for _, tc := range tests {
+		reading, ok := Decode(tc.payload)
+		if !ok {
+			t.Errorf("%s: not decoded", tc.name)
+			continue
+		}
+		if reading.Decoder != tc.decoder {
+			t.Errorf("%s: expected decoder %s, got %s", tc.name, tc.decoder, reading.Decoder)
+		}
+		if len(reading.Measurements) != len(tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, reading.Measurements)
+			continue
+		}
+		for i, m := range reading.Measurements {
+			want := tc.want[i]
+			if m.Quantity != want.Quantity || m.Unit != want.Unit || math.Abs(m.Value-want.Value) > 1e-9 {
+				t.Errorf("%s: expected %s %v, got %s %v", tc.name, want.Quantity, want, m.Quantity, m)
+			}
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, p := range []payload{
+		{},
+		{manufacturerData: map[uint16][]byte{RuuviCompanyID: {0x05, 0x12}}},
+		{manufacturerData: map[uint16][]byte{0xffff: {0x05}}},
+		{serviceData: map[bluetooth.UUID][]byte{BTHomeUUID: {0x41, 0x02, 0xca, 0x09}}}, // encrypted
+		{serviceData: map[bluetooth.UUID][]byte{BTHomeUUID: {0x40, 0xf0, 0x00}}},       // unknown object
+	} {
+		if reading, ok := Decode(p); ok {
+			t.Errorf("expected no reading for %+v, got %+v", p, reading)
+		}
+	}
+}
+
+func TestDecodeOrder(t *testing.T) {
+	// Both entries decode; the one with the lower UUID must always win.
+	p := payload{serviceData: map[bluetooth.UUID][]byte{
+		BTHomeUUID:               mustHex("4002ca09"),
+		EnvironmentalSensingUUID: mustHex("a4c138112233" + "00e6" + "37" + "5a" + "0b8a" + "17"),
+	}}
+	for i := 0; i < 20; i++ {
+		reading, ok := DecodeScanResult(bluetooth.ScanResult{AdvertisementPayload: p})
+		if !ok || reading.Decoder != "xiaomi-atc" {
+			t.Fatalf("expected xiaomi-atc, got %q (%v)", reading.Decoder, ok)
+		}
+	}
+}
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,44 @@
+package decoders
+
+// Company identifiers used by Govee thermometers. They are not assigned to
+// Govee by the Bluetooth SIG, so the data is checked for its format as well.
+const (
+	GoveeH5075CompanyID = 0xec88 // H5072, H5075
+	GoveeH510xCompanyID = 0x0001 // H5101, H5102, H5177
+)
+
+// DecodeGoveeH5075 decodes the manufacturer data of Govee H5072 and H5075
+// thermometers.
+func DecodeGoveeH5075(data []byte) ([]Measurement, error) {
+	if len(data) != 6 || data[0] != 0x00 {
+		return nil, errUnknownFormat
+	}
+	return decodeGovee(data[1:4], data[4]), nil
+}
+
+// DecodeGoveeH510x decodes the manufacturer data of Govee H5101, H5102 and
+// H5177 thermometers.
+func DecodeGoveeH510x(data []byte) ([]Measurement, error) {
+	if len(data) != 6 || data[0] != 0x01 || data[1] != 0x01 {
+		return nil, errUnknownFormat
+	}
+	return decodeGovee(data[2:5], data[5]), nil
+}
+
+// decodeGovee decodes the 24-bit value that holds the temperature in
+// thousands and the humidity in the remainder, both in tenths. The top bit is
+// the sign of the temperature.
+func decodeGovee(value []byte, battery byte) []Measurement {
+	v := uint32(value[0])<<16 | uint32(value[1])<<8 | uint32(value[2])
+	negative := v&0x800000 != 0
+	v &^= 0x800000
+	temperature := float64(v/1000) / 10
+	if negative {
+		temperature = -temperature
+	}
+	return []Measurement{
+		{Temperature, temperature, Celsius},
+		{Humidity, float64(v%1000) / 10, Percent},
+		{Battery, float64(battery), Percent},
+	}
+}
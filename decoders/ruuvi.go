@@ -0,0 +1,73 @@
+package decoders
+
+import (
+	"encoding/binary"
+)
+
+// RuuviCompanyID is the company identifier of Ruuvi Innovations.
+const RuuviCompanyID = 0x0499
+
+// DecodeRuuvi3 decodes RuuviTag data format 3 (RAWv1).
+func DecodeRuuvi3(data []byte) ([]Measurement, error) {
+	if len(data) < 1 || data[0] != 3 {
+		return nil, errUnknownFormat
+	}
+	if len(data) < 14 {
+		return nil, errShortData
+	}
+	// The temperature is the integer part in sign and magnitude, followed by
+	// the fraction in hundredths.
+	temperature := float64(data[2]&0x7f) + float64(data[3])/100
+	if data[2]&0x80 != 0 {
+		temperature = -temperature
+	}
+	return []Measurement{
+		{Temperature, temperature, Celsius},
+		{Humidity, float64(data[1]) / 2, Percent},
+		{Pressure, (float64(binary.BigEndian.Uint16(data[4:6])) + 50000) / 100, Hectopascal},
+		{AccelerationX, float64(int16(binary.BigEndian.Uint16(data[6:8]))) / 1000, StandardGravity},
+		{AccelerationY, float64(int16(binary.BigEndian.Uint16(data[8:10]))) / 1000, StandardGravity},
+		{AccelerationZ, float64(int16(binary.BigEndian.Uint16(data[10:12]))) / 1000, StandardGravity},
+		{Voltage, float64(binary.BigEndian.Uint16(data[12:14])) / 1000, Volt},
+	}, nil
+}
+
+// DecodeRuuvi5 decodes RuuviTag data format 5 (RAWv2). Values the tag marks
+// as invalid are left out.
+func DecodeRuuvi5(data []byte) ([]Measurement, error) {
+	if len(data) < 1 || data[0] != 5 {
+		return nil, errUnknownFormat
+	}
+	if len(data) < 18 {
+		return nil, errShortData
+	}
+	var measurements []Measurement
+	if v := int16(binary.BigEndian.Uint16(data[1:3])); v != -0x8000 {
+		measurements = append(measurements, Measurement{Temperature, float64(v) * 0.005, Celsius})
+	}
+	if v := binary.BigEndian.Uint16(data[3:5]); v != 0xffff {
+		measurements = append(measurements, Measurement{Humidity, float64(v) * 0.0025, Percent})
+	}
+	if v := binary.BigEndian.Uint16(data[5:7]); v != 0xffff {
+		measurements = append(measurements, Measurement{Pressure, (float64(v) + 50000) / 100, Hectopascal})
+	}
+	for i, quantity := range []Quantity{AccelerationX, AccelerationY, AccelerationZ} {
+		if v := int16(binary.BigEndian.Uint16(data[7+i*2:])); v != -0x8000 {
+			measurements = append(measurements, Measurement{quantity, float64(v) / 1000, StandardGravity})
+		}
+	}
+	power := binary.BigEndian.Uint16(data[13:15])
+	if v := power >> 5; v != 0x7ff {
+		measurements = append(measurements, Measurement{Voltage, float64(v+1600) / 1000, Volt})
+	}
+	if v := power & 0x1f; v != 0x1f {
+		measurements = append(measurements, Measurement{TxPower, float64(v)*2 - 40, DBm})
+	}
+	if v := data[15]; v != 0xff {
+		measurements = append(measurements, Measurement{MovementCount, float64(v), NoUnit})
+	}
+	if v := binary.BigEndian.Uint16(data[16:18]); v != 0xffff {
+		measurements = append(measurements, Measurement{SequenceNumber, float64(v), NoUnit})
+	}
+	return measurements, nil
+}
@@ -0,0 +1,44 @@
+package decoders
+
+import (
+	"encoding/binary"
+
+	"github.com/GKoSon/gobluetooth"
+)
+
+// EnvironmentalSensingUUID is the service UUID under which the ATC1441 and
+// pvvx custom firmware for Xiaomi thermometers such as the LYWSD03MMC send
+// their readings.
+var EnvironmentalSensingUUID = bluetooth.New16BitUUID(0x181a)
+
+// DecodeATC decodes the ATC1441 format of the custom firmware for Xiaomi
+// thermometers: the MAC address, temperature, humidity, battery level,
+// battery voltage and a frame counter, in big endian.
+func DecodeATC(data []byte) ([]Measurement, error) {
+	if len(data) != 13 {
+		return nil, errUnknownFormat
+	}
+	return []Measurement{
+		{Temperature, float64(int16(binary.BigEndian.Uint16(data[6:8]))) / 10, Celsius},
+		{Humidity, float64(data[8]), Percent},
+		{Battery, float64(data[9]), Percent},
+		{Voltage, float64(binary.BigEndian.Uint16(data[10:12])) / 1000, Volt},
+		{PacketID, float64(data[12]), NoUnit},
+	}, nil
+}
+
+// DecodePVVX decodes the custom format of the pvvx firmware for Xiaomi
+// thermometers: the MAC address, temperature, humidity, battery voltage,
+// battery level, a frame counter and flags, in little endian.
+func DecodePVVX(data []byte) ([]Measurement, error) {
+	if len(data) != 15 {
+		return nil, errUnknownFormat
+	}
+	return []Measurement{
+		{Temperature, float64(int16(binary.LittleEndian.Uint16(data[6:8]))) / 100, Celsius},
+		{Humidity, float64(binary.LittleEndian.Uint16(data[8:10])) / 100, Percent},
+		{Voltage, float64(binary.LittleEndian.Uint16(data[10:12])) / 1000, Volt},
+		{Battery, float64(data[12]), Percent},
+		{PacketID, float64(data[13]), NoUnit},
+	}, nil
+}
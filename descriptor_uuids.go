@@ -0,0 +1,86 @@
+// Code generated by bin/gen-descriptor-uuids; DO NOT EDIT.
+// This file was generated on 2026-10-19 12:18:13.424069674 +0000 UTC m=+0.000356575 from data/descriptor_uuids.json, a curated
+// subset of the descriptor UUIDs in
+// https://github.com/NordicSemiconductor/bluetooth-numbers-database/blob/master/v1/descriptor_uuids.json
+// Values outside the subset are treated as unknown.
+
+package bluetooth
+
+var (
+
+	// DescriptorUUIDCharacteristicExtendedProperties - Characteristic Extended Properties
+	DescriptorUUIDCharacteristicExtendedProperties = New16BitUUID(0x2900)
+
+	// DescriptorUUIDCharacteristicUserDescription - Characteristic User Description
+	DescriptorUUIDCharacteristicUserDescription = New16BitUUID(0x2901)
+
+	// DescriptorUUIDClientCharacteristicConfiguration - Client Characteristic Configuration
+	DescriptorUUIDClientCharacteristicConfiguration = New16BitUUID(0x2902)
+
+	// DescriptorUUIDServerCharacteristicConfiguration - Server Characteristic Configuration
+	DescriptorUUIDServerCharacteristicConfiguration = New16BitUUID(0x2903)
+
+	// DescriptorUUIDCharacteristicPresentationFormat - Characteristic Presentation Format
+	DescriptorUUIDCharacteristicPresentationFormat = New16BitUUID(0x2904)
+
+	// DescriptorUUIDCharacteristicAggregateFormat - Characteristic Aggregate Format
+	DescriptorUUIDCharacteristicAggregateFormat = New16BitUUID(0x2905)
+
+	// DescriptorUUIDValidRange - Valid Range
+	DescriptorUUIDValidRange = New16BitUUID(0x2906)
+
+	// DescriptorUUIDExternalReportReference - External Report Reference
+	DescriptorUUIDExternalReportReference = New16BitUUID(0x2907)
+
+	// DescriptorUUIDReportReference - Report Reference
+	DescriptorUUIDReportReference = New16BitUUID(0x2908)
+
+	// DescriptorUUIDNumberOfDigitals - Number of Digitals
+	DescriptorUUIDNumberOfDigitals = New16BitUUID(0x2909)
+
+	// DescriptorUUIDValueTriggerSetting - Value Trigger Setting
+	DescriptorUUIDValueTriggerSetting = New16BitUUID(0x290A)
+
+	// DescriptorUUIDEnvironmentalSensingConfiguration - Environmental Sensing Configuration
+	DescriptorUUIDEnvironmentalSensingConfiguration = New16BitUUID(0x290B)
+
+	// DescriptorUUIDEnvironmentalSensingMeasurement - Environmental Sensing Measurement
+	DescriptorUUIDEnvironmentalSensingMeasurement = New16BitUUID(0x290C)
+
+	// DescriptorUUIDEnvironmentalSensingTriggerSetting - Environmental Sensing Trigger Setting
+	DescriptorUUIDEnvironmentalSensingTriggerSetting = New16BitUUID(0x290D)
+
+	// DescriptorUUIDTimeTriggerSetting - Time Trigger Setting
+	DescriptorUUIDTimeTriggerSetting = New16BitUUID(0x290E)
+
+	// DescriptorUUIDCompleteBREDRTransportBlockData - Complete BR-EDR Transport Block Data
+	DescriptorUUIDCompleteBREDRTransportBlockData = New16BitUUID(0x290F)
+
+	// DescriptorUUIDObservationSchedule - Observation Schedule
+	DescriptorUUIDObservationSchedule = New16BitUUID(0x2910)
+
+	// DescriptorUUIDValidRangeAndAccuracy - Valid Range and Accuracy
+	DescriptorUUIDValidRangeAndAccuracy = New16BitUUID(0x2911)
+)
+
+// descriptorUUIDNames maps the standard descriptor UUIDs to their names.
+var descriptorUUIDNames = map[UUID]string{
+	DescriptorUUIDCharacteristicExtendedProperties:   "Characteristic Extended Properties",
+	DescriptorUUIDCharacteristicUserDescription:      "Characteristic User Description",
+	DescriptorUUIDClientCharacteristicConfiguration:  "Client Characteristic Configuration",
+	DescriptorUUIDServerCharacteristicConfiguration:  "Server Characteristic Configuration",
+	DescriptorUUIDCharacteristicPresentationFormat:   "Characteristic Presentation Format",
+	DescriptorUUIDCharacteristicAggregateFormat:      "Characteristic Aggregate Format",
+	DescriptorUUIDValidRange:                         "Valid Range",
+	DescriptorUUIDExternalReportReference:            "External Report Reference",
+	DescriptorUUIDReportReference:                    "Report Reference",
+	DescriptorUUIDNumberOfDigitals:                   "Number of Digitals",
+	DescriptorUUIDValueTriggerSetting:                "Value Trigger Setting",
+	DescriptorUUIDEnvironmentalSensingConfiguration:  "Environmental Sensing Configuration",
+	DescriptorUUIDEnvironmentalSensingMeasurement:    "Environmental Sensing Measurement",
+	DescriptorUUIDEnvironmentalSensingTriggerSetting: "Environmental Sensing Trigger Setting",
+	DescriptorUUIDTimeTriggerSetting:                 "Time Trigger Setting",
+	DescriptorUUIDCompleteBREDRTransportBlockData:    "Complete BR-EDR Transport Block Data",
+	DescriptorUUIDObservationSchedule:                "Observation Schedule",
+	DescriptorUUIDValidRangeAndAccuracy:              "Valid Range and Accuracy",
+}
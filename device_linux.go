@@ -0,0 +1,411 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+var (
+	errDeviceNotFound = errors.New("bluetooth: device not found")
+	errDisconnected   = errors.New("bluetooth: device disconnected")
+)
+
+// DeviceEvent is a property change of a remote device as sent by Device.Watch.
+// It is one of the *Changed types in this file.
+type DeviceEvent interface {
+	deviceEvent()
+}
+
+// ConnectedChanged is sent when the device connects or disconnects.
+type ConnectedChanged struct {
+	Connected bool
+}
+
+// ServicesResolvedChanged is sent when service discovery on the device has
+// finished, or when the services are no longer available.
+type ServicesResolvedChanged struct {
+	ServicesResolved bool
+}
+
+// RSSIChanged is sent when a new advertisement of the device was received.
+type RSSIChanged struct {
+	RSSI int16
+}
+
+// TxPowerChanged is sent when the advertised transmit power changes.
+type TxPowerChanged struct {
+	TxPower int16
+}
+
+// NameChanged is sent when the remote name of the device, or the system name
+// of the adapter, changes.
+type NameChanged struct {
+	Name string
+}
+
+// AliasChanged is sent when the alias of the device or adapter changes.
+type AliasChanged struct {
+	Alias string
+}
+
+// PairedChanged is sent when the device is paired or unpaired.
+type PairedChanged struct {
+	Paired bool
+}
+
+// TrustedChanged is sent when the device is marked as (un)trusted.
+type TrustedChanged struct {
+	Trusted bool
+}
+
+// BlockedChanged is sent when the device is (un)blocked.
+type BlockedChanged struct {
+	Blocked bool
+}
+
+// UUIDsChanged is sent when the list of service UUIDs of the device or
+// adapter changes.
+type UUIDsChanged struct {
+	UUIDs []UUID
+}
+
+func (ConnectedChanged) deviceEvent()        {}
+func (ServicesResolvedChanged) deviceEvent() {}
+func (RSSIChanged) deviceEvent()             {}
+func (TxPowerChanged) deviceEvent()          {}
+func (NameChanged) deviceEvent()             {}
+func (AliasChanged) deviceEvent()            {}
+func (PairedChanged) deviceEvent()           {}
+func (TrustedChanged) deviceEvent()          {}
+func (BlockedChanged) deviceEvent()          {}
+func (UUIDsChanged) deviceEvent()            {}
+
+// makeDeviceEvent converts a changed Device1 property into a DeviceEvent. It
+// returns nil for properties that have no event type.
+func makeDeviceEvent(name string, val dbus.Variant) DeviceEvent {
+	switch v := val.Value().(type) {
+	case bool:
+		switch name {
+		case "Connected":
+			return ConnectedChanged{v}
+		case "ServicesResolved":
+			return ServicesResolvedChanged{v}
+		case "Paired":
+			return PairedChanged{v}
+		case "Trusted":
+			return TrustedChanged{v}
+		case "Blocked":
+			return BlockedChanged{v}
+		}
+	case int16:
+		switch name {
+		case "RSSI":
+			return RSSIChanged{v}
+		case "TxPower":
+			return TxPowerChanged{v}
+		}
+	case string:
+		switch name {
+		case "Name":
+			return NameChanged{v}
+		case "Alias":
+			return AliasChanged{v}
+		}
+	case []string:
+		if name == "UUIDs" {
+			return UUIDsChanged{parseUUIDs(v)}
+		}
+	}
+	return nil
+}
+
+// Address returns the Bluetooth address of this device.
+func (d *Device) Address() Address {
+	// Assume the Address property is well-formed.
+	mac, _ := ParseMAC(d.device.Properties.Address)
+	a := Address{MACAddress{MAC: mac}}
+	a.SetRandom(d.device.Properties.AddressType == "random")
+	return a
+}
+
+// Adapter returns the adapter this device was found on.
+func (d *Device) Adapter() *Adapter {
+	return d.adapter
+}
+
+// Name returns the remote name of the device, or an empty string if it is not
+// known.
+func (d *Device) Name() string {
+	name, err := d.device.GetName()
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// Alias returns the alias of the device. If no alias was set, BlueZ returns
+// the remote name or the address.
+func (d *Device) Alias() string {
+	alias, err := d.device.GetAlias()
+	if err != nil {
+		return ""
+	}
+	return alias
+}
+
+// RSSI returns the signal strength of the last received advertisement, or 0
+// when the device is not being discovered.
+func (d *Device) RSSI() int16 {
+	rssi, err := d.device.GetRSSI()
+	if err != nil {
+		return 0
+	}
+	return rssi
+}
+
+// TxPower returns the advertised transmit power in dBm. The second return
+// value is false if the device does not advertise its transmit power.
+func (d *Device) TxPower() (int16, bool) {
+	txPower, err := d.device.GetTxPower()
+	if err != nil {
+		return 0, false
+	}
+	return txPower, true
+}
+
+// Appearance returns the external appearance of the device as defined by the
+// Bluetooth SIG, or 0 if unknown.
+func (d *Device) Appearance() uint16 {
+	appearance, err := d.device.GetAppearance()
+	if err != nil {
+		return 0
+	}
+	return appearance
+}
+
+// Paired returns whether the device is paired.
+func (d *Device) Paired() bool {
+	paired, err := d.device.GetPaired()
+	if err != nil {
+		return false
+	}
+	return paired
+}
+
+// ServicesResolved returns whether service discovery on this device has
+// finished.
+func (d *Device) ServicesResolved() bool {
+	resolved, err := d.device.GetServicesResolved()
+	if err != nil {
+		return false
+	}
+	return resolved
+}
+
+// Watch returns a channel with property changes of this device, such as
+// ConnectedChanged, RSSIChanged and ServicesResolvedChanged. The channel is
+// closed when the context is canceled.
+func (d *Device) Watch(ctx context.Context) (<-chan DeviceEvent, error) {
+	if err := d.stack.check(); err != nil {
+		return nil, err
+	}
+	path := d.device.Path()
+	signal, cancel, err := watchPropertiesChanged(path)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan DeviceEvent, 16)
+	go func() {
+		defer close(events)
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signal:
+				changes, ok := propertiesChangedFor(sig, path, "org.bluez.Device1")
+				if !ok {
+					continue
+				}
+				for name, val := range changes {
+					event := makeDeviceEvent(name, val)
+					if event == nil {
+						continue
+					}
+					select {
+					case events <- event:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// Disconnected returns a channel that is closed when the connection to this
+// device is lost, for any reason. It is closed right away if the device is not
+// connected.
+func (d *Device) Disconnected() <-chan struct{} {
+	d.disconnectedOnce.Do(d.watchDisconnected)
+	return d.disconnected
+}
+
+// watchDisconnected starts a goroutine that closes d.disconnected once the
+// Connected property becomes false or the device object is removed, and calls
+// the connect handler of the adapter if the device was connected.
+func (d *Device) watchDisconnected() {
+	d.disconnected = make(chan struct{})
+	path := d.device.Path()
+	signal, cancel, err := watchSignals(signalFilter{
+		Path:      path,
+		Interface: "org.freedesktop.DBus.Properties",
+		Member:    "PropertiesChanged",
+	}, signalFilter{
+		Path:      "/",
+		Interface: "org.freedesktop.DBus.ObjectManager",
+		Member:    "InterfacesRemoved",
+		Object:    path,
+	})
+	go func() {
+		connected := false
+		defer func() {
+			close(d.disconnected)
+			gattQueues.drop(string(path))
+			if d.adapter != nil {
+				d.adapter.untrackConnection(d)
+			}
+			if connected && d.adapter != nil && d.adapter.connectHandler != nil {
+				d.adapter.connectHandler(d.Address(), false)
+			}
+		}()
+		if err != nil {
+			// No signals available, fall back to polling.
+			for d.IsConnected() {
+				connected = true
+				time.Sleep(time.Second)
+			}
+			return
+		}
+		defer cancel()
+
+		// Check after subscribing, so that a disconnect that happened in
+		// between is not missed.
+		if !d.IsConnected() {
+			return
+		}
+		connected = true
+		for {
+			var sig *dbus.Signal
+			select {
+			case sig = <-signal:
+			case <-d.stack.lost:
+				// bluetoothd stopped, taking the connection with it.
+				return
+			}
+			if isDeviceRemoved(sig, path) {
+				// Removed from the cache, for example by FlushUnbonded.
+				return
+			}
+			changes, ok := propertiesChangedFor(sig, path, "org.bluez.Device1")
+			if !ok {
+				continue
+			}
+			if val, ok := changes["Connected"]; ok {
+				if connected, _ := val.Value().(bool); !connected {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// DisconnectContext disconnects from the device and waits until BlueZ reports
+// the connection as gone. It returns the context error if that did not happen
+// before the context is done.
+func (d *Device) DisconnectContext(ctx context.Context) error {
+	if err := d.stack.check(); err != nil {
+		return err
+	}
+	disconnected := d.Disconnected()
+
+	bus, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	// Call Disconnect asynchronously: BlueZ may take a long time to reply,
+	// while the Connected property often changes earlier.
+	call := bus.Object("org.bluez", d.device.Path()).Go("org.bluez.Device1.Disconnect", 0, make(chan *dbus.Call, 1))
+
+	select {
+	case <-disconnected:
+		return nil
+	case <-call.Done:
+		if call.Err != nil {
+			return call.Err
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-disconnected:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// waitServicesResolved waits until BlueZ has resolved the services of the
+// device. It returns an error if the device disconnects first.
+func (d *Device) waitServicesResolved(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := d.Watch(ctx)
+	if err != nil {
+		return err
+	}
+	// Check after subscribing, so the change cannot be missed.
+	if d.ServicesResolved() {
+		return nil
+	}
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return ctx.Err()
+			}
+			if event, ok := event.(ServicesResolvedChanged); ok && event.ServicesResolved {
+				return nil
+			}
+		case <-d.Disconnected():
+			return errDisconnected
+		}
+	}
+}
+
+// isDeviceRemoved returns whether the signal is an InterfacesRemoved signal
+// that removes the Device1 object at the given path.
+func isDeviceRemoved(sig *dbus.Signal, path dbus.ObjectPath) bool {
+	if sig == nil || sig.Name != "org.freedesktop.DBus.ObjectManager.InterfacesRemoved" || len(sig.Body) < 2 {
+		return false
+	}
+	if removed, ok := sig.Body[0].(dbus.ObjectPath); !ok || removed != path {
+		return false
+	}
+	ifaces, _ := sig.Body[1].([]string)
+	for _, iface := range ifaces {
+		if iface == "org.bluez.Device1" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,386 @@
+package bluetooth
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultDeviceTTL is how long a device stays in a DeviceTable without being
+// seen when DeviceTableOptions.TTL is not set.
+const defaultDeviceTTL = 30 * time.Second
+
+// RSSISmoothing selects how a DeviceTable smooths the RSSI of a device.
+type RSSISmoothing uint8
+
+const (
+	// SmoothEMA uses an exponential moving average. It is the default.
+	SmoothEMA RSSISmoothing = iota
+
+	// SmoothKalman uses a one-dimensional Kalman filter, which follows real
+	// changes faster than an EMA with the same amount of noise reduction.
+	SmoothKalman
+
+	// SmoothNone uses the last RSSI as is.
+	SmoothNone
+)
+
+// DeviceTableOptions configures a DeviceTable. The zero value is usable.
+type DeviceTableOptions struct {
+	// TTL is how long a device stays in the table after it was last seen.
+	// It defaults to 30 seconds.
+	TTL time.Duration
+
+	// Smoothing selects the RSSI filter.
+	Smoothing RSSISmoothing
+
+	// Alpha is the weight of a new sample for SmoothEMA, between 0 and 1. It
+	// defaults to 0.3.
+	Alpha float64
+
+	// ProcessNoise and MeasurementNoise tune SmoothKalman. They default to
+	// 0.1 and 4.
+	ProcessNoise     float64
+	MeasurementNoise float64
+
+	// RSSIChange is the change of the smoothed RSSI, in dBm, after which a
+	// DeviceUpdated event is sent. Zero only sends DeviceUpdated when the
+	// advertisement fields change.
+	RSSIChange float64
+
+	// Events is called for every event of the table. It is called without
+	// the table lock held, so it may call methods of the table.
+	Events func(DeviceTableEvent)
+}
+
+// DeviceTableEventType is the type of a DeviceTableEvent.
+type DeviceTableEventType uint8
+
+const (
+	// DeviceAppeared is sent when a device is seen that is not in the table.
+	DeviceAppeared DeviceTableEventType = iota + 1
+
+	// DeviceUpdated is sent when the advertisement fields or the smoothed
+	// RSSI of a device changed, see DeviceTableOptions.RSSIChange.
+	DeviceUpdated
+
+	// DeviceLost is sent when a device has not been seen for the TTL and is
+	// removed from the table.
+	DeviceLost
+)
+
+// String returns a human-readable version of the event type.
+func (t DeviceTableEventType) String() string {
+	switch t {
+	case DeviceAppeared:
+		return "appeared"
+	case DeviceUpdated:
+		return "updated"
+	case DeviceLost:
+		return "lost"
+	}
+	return "unknown"
+}
+
+// DeviceTableEvent is sent by a DeviceTable when a device appears, changes or
+// is lost.
+type DeviceTableEvent struct {
+	Type  DeviceTableEventType
+	Entry DeviceEntry
+}
+
+// DeviceEntry is the aggregated state of one device in a DeviceTable.
+type DeviceEntry struct {
+	// Address of the device, as in the last scan result.
+	Address Addresser
+
+	// FirstSeen and LastSeen are the times the device was first and last
+	// seen.
+	FirstSeen time.Time
+	LastSeen  time.Time
+
+	// Count is the number of scan results for this device.
+	Count int
+
+	// RSSI is the last RSSI that was received, or 0 if none was.
+	RSSI int16
+
+	// SmoothedRSSI is the RSSI after smoothing, or 0 if no RSSI was received.
+	SmoothedRSSI float64
+
+	// Fields are the advertisement fields of all scan results merged: the
+	// latest local name and transmit power, the union of service UUIDs and
+	// the latest manufacturer and service data for each key.
+	Fields AdvertisementFields
+}
+
+// deviceTableEntry is a DeviceEntry with the state of its RSSI filter.
+type deviceTableEntry struct {
+	DeviceEntry
+	reported float64 // smoothed RSSI at the last event
+	variance float64 // error estimate of the Kalman filter
+}
+
+// DeviceTable keeps one entry per device from a stream of scan results. Scan
+// reports every property change of a device separately; a DeviceTable merges
+// them and turns them into Appeared, Updated and Lost events.
+type DeviceTable struct {
+	options DeviceTableOptions
+	lock    sync.Mutex
+	entries map[string]*deviceTableEntry
+	now     func() time.Time
+}
+
+// NewDeviceTable returns a new, empty DeviceTable.
+func NewDeviceTable(options DeviceTableOptions) *DeviceTable {
+	if options.TTL <= 0 {
+		options.TTL = defaultDeviceTTL
+	}
+	if options.Alpha <= 0 || options.Alpha > 1 {
+		options.Alpha = 0.3
+	}
+	if options.ProcessNoise <= 0 {
+		options.ProcessNoise = 0.1
+	}
+	if options.MeasurementNoise <= 0 {
+		options.MeasurementNoise = 4
+	}
+	return &DeviceTable{
+		options: options,
+		entries: make(map[string]*deviceTableEntry),
+		now:     time.Now,
+	}
+}
+
+// HandleScanResult adds a scan result to the table. It has the signature of
+// the Scan callback, so it can be passed to Scan directly.
+func (t *DeviceTable) HandleScanResult(adapter *Adapter, result ScanResult) {
+	t.Add(result)
+}
+
+// Add adds a scan result to the table.
+func (t *DeviceTable) Add(result ScanResult) {
+	key := scanResultKey(result)
+	if key == "" {
+		return
+	}
+	now := t.now()
+
+	t.lock.Lock()
+	e, ok := t.entries[key]
+	eventType := DeviceUpdated
+	if !ok {
+		e = &deviceTableEntry{DeviceEntry: DeviceEntry{FirstSeen: now}}
+		t.entries[key] = e
+		eventType = DeviceAppeared
+	}
+	e.Address = result.Address
+	e.LastSeen = now
+	e.Count++
+	changed := mergeAdvertisement(&e.Fields, result.AdvertisementPayload)
+	if result.RSSI != 0 {
+		t.smooth(e, result.RSSI)
+	}
+	if eventType == DeviceUpdated && !changed {
+		if t.options.RSSIChange <= 0 || math.Abs(e.SmoothedRSSI-e.reported) < t.options.RSSIChange {
+			eventType = 0
+		}
+	}
+	var event DeviceTableEvent
+	if eventType != 0 {
+		e.reported = e.SmoothedRSSI
+		event = DeviceTableEvent{Type: eventType, Entry: e.copy()}
+	}
+	t.lock.Unlock()
+
+	if eventType != 0 && t.options.Events != nil {
+		t.options.Events(event)
+	}
+}
+
+// smooth updates the RSSI of the entry with a new sample.
+func (t *DeviceTable) smooth(e *deviceTableEntry, rssi int16) {
+	sample := float64(rssi)
+	if e.RSSI == 0 || t.options.Smoothing == SmoothNone {
+		// First sample, or no smoothing.
+		e.SmoothedRSSI = sample
+		e.variance = t.options.MeasurementNoise
+	} else if t.options.Smoothing == SmoothKalman {
+		e.variance += t.options.ProcessNoise
+		gain := e.variance / (e.variance + t.options.MeasurementNoise)
+		e.SmoothedRSSI += gain * (sample - e.SmoothedRSSI)
+		e.variance *= 1 - gain
+	} else {
+		e.SmoothedRSSI += t.options.Alpha * (sample - e.SmoothedRSSI)
+	}
+	e.RSSI = rssi
+}
+
+// Expire removes the devices that have not been seen for the TTL, sends a
+// DeviceLost event for each and returns them.
+func (t *DeviceTable) Expire() []DeviceEntry {
+	now := t.now()
+	var lost []DeviceEntry
+	t.lock.Lock()
+	for key, e := range t.entries {
+		if now.Sub(e.LastSeen) > t.options.TTL {
+			delete(t.entries, key)
+			lost = append(lost, e.copy())
+		}
+	}
+	t.lock.Unlock()
+
+	sortEntries(lost)
+	if t.options.Events != nil {
+		for _, entry := range lost {
+			t.options.Events(DeviceTableEvent{Type: DeviceLost, Entry: entry})
+		}
+	}
+	return lost
+}
+
+// Run calls Expire regularly until the context is canceled.
+func (t *DeviceTable) Run(ctx context.Context) {
+	interval := t.options.TTL / 4
+	if interval < 100*time.Millisecond {
+		interval = 100 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Expire()
+		}
+	}
+}
+
+// Get returns the entry for the device with the given address, as returned
+// by Addresser.String. For a resolved private address, this is the identity
+// address.
+func (t *DeviceTable) Get(address string) (DeviceEntry, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	e, ok := t.entries[address]
+	if !ok {
+		return DeviceEntry{}, false
+	}
+	return e.copy(), true
+}
+
+// Entries returns all entries in the table, most recently seen first.
+func (t *DeviceTable) Entries() []DeviceEntry {
+	t.lock.Lock()
+	entries := make([]DeviceEntry, 0, len(t.entries))
+	for _, e := range t.entries {
+		entries = append(entries, e.copy())
+	}
+	t.lock.Unlock()
+	sortEntries(entries)
+	return entries
+}
+
+// Len returns the number of devices in the table.
+func (t *DeviceTable) Len() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.entries)
+}
+
+// copy returns a copy of the entry that does not share memory with the table.
+func (e *deviceTableEntry) copy() DeviceEntry {
+	entry := e.DeviceEntry
+	entry.Fields.ServiceUUIDs = append([]UUID(nil), e.Fields.ServiceUUIDs...)
+	if e.Fields.ManufacturerData != nil {
+		entry.Fields.ManufacturerData = make(map[uint16][]byte, len(e.Fields.ManufacturerData))
+		for id, data := range e.Fields.ManufacturerData {
+			entry.Fields.ManufacturerData[id] = data
+		}
+	}
+	if e.Fields.ServiceData != nil {
+		entry.Fields.ServiceData = make(map[UUID][]byte, len(e.Fields.ServiceData))
+		for uuid, data := range e.Fields.ServiceData {
+			entry.Fields.ServiceData[uuid] = data
+		}
+	}
+	return entry
+}
+
+// sortEntries sorts entries by LastSeen, most recent first.
+func sortEntries(entries []DeviceEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastSeen.After(entries[j].LastSeen)
+	})
+}
+
+// scanResultKey returns the key of the device of a scan result. Devices that
+// were resolved to an identity address are kept under that address, so a
+// rotating private address does not create new entries.
+func scanResultKey(result ScanResult) string {
+	if result.Identity != nil {
+		return result.Identity.String()
+	}
+	if result.Address != nil {
+		return result.Address.String()
+	}
+	return result.MUKAAddress
+}
+
+// mergeAdvertisement merges the fields of the payload into fields and returns
+// whether anything changed. Data is copied, as the payload may be reused.
+func mergeAdvertisement(fields *AdvertisementFields, payload AdvertisementPayload) bool {
+	if payload == nil {
+		return false
+	}
+	changed := false
+	if name := payload.LocalName(); name != "" && name != fields.LocalName {
+		fields.LocalName = name
+		changed = true
+	}
+	if p, ok := payload.(*advertisementFields); ok {
+		for _, uuid := range p.AdvertisementFields.ServiceUUIDs {
+			found := false
+			for _, u := range fields.ServiceUUIDs {
+				if u == uuid {
+					found = true
+					break
+				}
+			}
+			if !found {
+				fields.ServiceUUIDs = append(fields.ServiceUUIDs, uuid)
+				changed = true
+			}
+		}
+	}
+	for uuid, data := range payload.ServiceData() {
+		if old, ok := fields.ServiceData[uuid]; ok && bytes.Equal(old, data) {
+			continue
+		}
+		if fields.ServiceData == nil {
+			fields.ServiceData = make(map[UUID][]byte)
+		}
+		fields.ServiceData[uuid] = append([]byte(nil), data...)
+		changed = true
+	}
+	if txPower, ok := payload.TxPower(); ok && (!fields.HasTxPower || txPower != fields.TxPower) {
+		fields.TxPower = txPower
+		fields.HasTxPower = true
+		changed = true
+	}
+	for id, data := range payload.ManufacturerData() {
+		if old, ok := fields.ManufacturerData[id]; ok && bytes.Equal(old, data) {
+			continue
+		}
+		if fields.ManufacturerData == nil {
+			fields.ManufacturerData = make(map[uint16][]byte)
+		}
+		fields.ManufacturerData[id] = append([]byte(nil), data...)
+		changed = true
+	}
+	return changed
+}
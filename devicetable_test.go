@@ -0,0 +1,66 @@
+package bluetooth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeviceTable(t *testing.T) {
+	var events []DeviceTableEvent
+	table := NewDeviceTable(DeviceTableOptions{
+		TTL:    10 * time.Second,
+		Events: func(e DeviceTableEvent) { events = append(events, e) },
+	})
+	now := time.Unix(1000, 0)
+	table.now = func() time.Time { return now }
+
+	mac, _ := ParseMAC("11:22:33:AA:BB:CC")
+	address := MACAddress{MAC: mac}
+	result := func(rssi int16, name string, data []byte) ScanResult {
+		fields := AdvertisementFields{LocalName: name}
+		if data != nil {
+			fields.ManufacturerData = map[uint16][]byte{0x004c: data}
+		}
+		return ScanResult{
+			Address:              address,
+			RSSI:                 rssi,
+			AdvertisementPayload: &advertisementFields{fields},
+		}
+	}
+
+	table.Add(result(-60, "", nil))
+	now = now.Add(time.Second)
+	table.Add(result(-70, "", nil))           // only the RSSI changed
+	table.Add(result(-70, "M_SHANGHAI", nil)) // the name arrived
+	table.Add(result(0, "", []byte{1, 2}))    // manufacturer data arrived
+	table.Add(result(0, "", []byte{1, 2}))    // duplicate
+
+	if len(events) != 3 || events[0].Type != DeviceAppeared || events[1].Type != DeviceUpdated || events[2].Type != DeviceUpdated {
+		t.Fatalf("unexpected events: %v", events)
+	}
+	entry, ok := table.Get(address.String())
+	if !ok {
+		t.Fatal("device not in table")
+	}
+	if entry.Count != 5 || entry.RSSI != -70 || entry.Fields.LocalName != "M_SHANGHAI" || len(entry.Fields.ManufacturerData[0x004c]) != 2 {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if entry.SmoothedRSSI >= -60 || entry.SmoothedRSSI <= -70 {
+		t.Errorf("expected a smoothed RSSI between -70 and -60, got %v", entry.SmoothedRSSI)
+	}
+	if !entry.FirstSeen.Equal(time.Unix(1000, 0)) || !entry.LastSeen.Equal(now) {
+		t.Errorf("unexpected first and last seen: %v, %v", entry.FirstSeen, entry.LastSeen)
+	}
+
+	now = now.Add(5 * time.Second)
+	if lost := table.Expire(); len(lost) != 0 {
+		t.Errorf("expired too early: %v", lost)
+	}
+	now = now.Add(10 * time.Second)
+	if lost := table.Expire(); len(lost) != 1 || table.Len() != 0 {
+		t.Errorf("expected the device to be lost, got %v", lost)
+	}
+	if events[len(events)-1].Type != DeviceLost {
+		t.Errorf("expected a lost event, got %v", events[len(events)-1].Type)
+	}
+}
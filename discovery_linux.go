@@ -0,0 +1,367 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiscoveryState is the state of the discovery coordinator of an adapter.
+type DiscoveryState struct {
+	// Requests is the number of components that want discovery to run.
+	Requests int
+
+	// Suspends is the number of components that need discovery to be paused,
+	// for example while connecting.
+	Suspends int
+
+	// Discovering is the Discovering property of the adapter as last seen.
+	Discovering bool
+}
+
+// Wanted returns whether discovery should be running in this state.
+func (s DiscoveryState) Wanted() bool {
+	return s.Requests > 0 && s.Suspends == 0
+}
+
+// discoveryCoordinator reference counts requests to start and suspend
+// discovery, and reconciles them with the Discovering property of the
+// adapter. The zero value is ready to use.
+type discoveryCoordinator struct {
+	lock     sync.Mutex
+	state    DiscoveryState
+	started  bool          // whether we have an active discovery session in BlueZ
+	closed   bool          // set by Adapter.Close, discovery stays off until Enable
+	done     chan struct{} // closed when closed is set, see closedChan
+	watching bool
+	unwatch  func()
+	retry    *time.Timer
+	watchers map[chan DiscoveryState]struct{}
+
+	// Serializes the StartDiscovery and StopDiscovery calls.
+	reconcileLock sync.Mutex
+}
+
+// RequestDiscovery asks for discovery to run. Discovery runs while there is
+// at least one request and no suspend. The returned function withdraws the
+// request; it must be called exactly once.
+func (a *Adapter) RequestDiscovery() (release func(), err error) {
+	c := &a.discovery
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return nil, errAdapterClosed
+	}
+	c.state.Requests++
+	c.notify()
+	c.lock.Unlock()
+	a.watchDiscovering()
+
+	err = a.reconcileDiscovery()
+	if err != nil {
+		c.lock.Lock()
+		c.state.Requests--
+		c.notify()
+		c.lock.Unlock()
+		a.reconcileDiscovery()
+		return nil, err
+	}
+	return a.discoveryRelease(&c.state.Requests), nil
+}
+
+// SuspendDiscovery pauses discovery, for example during a connection attempt
+// which is much more reliable while the radio is not scanning. Discovery
+// resumes when every suspend has been withdrawn and there is still a request.
+// The returned function withdraws the suspend; it must be called exactly
+// once.
+func (a *Adapter) SuspendDiscovery() (resume func()) {
+	c := &a.discovery
+	c.lock.Lock()
+	c.state.Suspends++
+	c.notify()
+	c.lock.Unlock()
+	a.watchDiscovering()
+
+	a.reconcileDiscovery()
+	return a.discoveryRelease(&c.state.Suspends)
+}
+
+// discoveryRelease returns a function that decrements the given counter once
+// and reconciles the discovery state.
+func (a *Adapter) discoveryRelease(counter *int) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.discovery.lock.Lock()
+			*counter--
+			a.discovery.notify()
+			a.discovery.lock.Unlock()
+			a.reconcileDiscovery()
+		})
+	}
+}
+
+// Chdiscovery is the channel that ScanPlus used to pass to DelayDiscovery.
+//
+// Deprecated: discovery is paused and resumed by the discovery coordinator,
+// see RequestDiscovery and SuspendDiscovery.
+var Chdiscovery = make(chan bool)
+
+// DelayDiscovery makes sure discovery runs six seconds after every value
+// received on start, for example after a connection attempt. It returns when
+// start or the adapter is closed.
+//
+// Deprecated: use RequestDiscovery to keep discovery running and
+// SuspendDiscovery to pause it while connecting.
+func (a *Adapter) DelayDiscovery(start chan bool) {
+	var release func()
+	defer func() {
+		if release != nil {
+			release()
+		}
+	}()
+	closed := a.closedChan()
+	var delay <-chan time.Time
+	for {
+		select {
+		case <-closed:
+			return
+		case _, ok := <-start:
+			if !ok {
+				return
+			}
+			if release == nil && delay == nil {
+				delay = time.After(6 * time.Second)
+			}
+		case <-delay:
+			delay = nil
+			var err error
+			release, err = a.RequestDiscovery()
+			if err == errAdapterClosed {
+				return
+			}
+			if err != nil {
+				log.Println("TinyGo DelayDiscovery", err)
+			}
+		}
+	}
+}
+
+// closedChan returns a channel that is closed when the adapter is closed with
+// Close, or right away if it is closed already.
+func (a *Adapter) closedChan() <-chan struct{} {
+	c := &a.discovery
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.done == nil {
+		c.done = make(chan struct{})
+		if c.closed {
+			close(c.done)
+		}
+	}
+	return c.done
+}
+
+// DiscoveryState returns the current state of the discovery coordinator.
+func (a *Adapter) DiscoveryState() DiscoveryState {
+	a.discovery.lock.Lock()
+	defer a.discovery.lock.Unlock()
+	return a.discovery.state
+}
+
+// WatchDiscovery returns a channel that receives the state of the discovery
+// coordinator every time it changes. Only the latest state is kept if the
+// receiver is slow. The channel is closed when the context is canceled.
+func (a *Adapter) WatchDiscovery(ctx context.Context) <-chan DiscoveryState {
+	c := &a.discovery
+	ch := make(chan DiscoveryState, 1)
+	c.lock.Lock()
+	if c.watchers == nil {
+		c.watchers = make(map[chan DiscoveryState]struct{})
+	}
+	c.watchers[ch] = struct{}{}
+	ch <- c.state
+	c.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		c.lock.Lock()
+		delete(c.watchers, ch)
+		close(ch)
+		c.lock.Unlock()
+	}()
+	return ch
+}
+
+// notify sends the current state to all watchers. It must be called
+// with the lock held.
+func (c *discoveryCoordinator) notify() {
+	for ch := range c.watchers {
+		// Replace a state that has not been received yet.
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- c.state
+	}
+}
+
+// reconcileDiscovery starts or stops discovery so that the adapter matches
+// the requested state. It returns the error of StartDiscovery, if any.
+func (a *Adapter) reconcileDiscovery() error {
+	c := &a.discovery
+	c.reconcileLock.Lock()
+	defer c.reconcileLock.Unlock()
+
+	c.lock.Lock()
+	wanted := c.state.Wanted() && !c.closed
+	started := c.started
+	c.lock.Unlock()
+
+	discovering, err := a.proxy().GetDiscovering()
+	if err != nil {
+		log.Println("TinyGo GetDiscovering", err)
+	}
+
+	if wanted && (!started || !discovering) {
+		// Also start when our session exists but the adapter is not
+		// discovering, for example after it has been powered off and on.
+		err = a.proxy().StartDiscovery()
+		if err != nil && !strings.Contains(err.Error(), "InProgress") {
+			log.Println("TinyGo StartDiscovery", err)
+			a.retryDiscovery()
+		} else {
+			err = nil
+			started = true
+			discovering = true
+		}
+	} else if !wanted && started {
+		err = a.proxy().StopDiscovery()
+		if err != nil {
+			log.Println("TinyGo StopDiscovery", err)
+			err = nil
+		}
+		started = false
+		discovering, _ = a.proxy().GetDiscovering()
+	}
+
+	c.lock.Lock()
+	c.started = started
+	if c.state.Discovering != discovering {
+		c.state.Discovering = discovering
+		c.notify()
+	}
+	c.lock.Unlock()
+	return err
+}
+
+// restartDiscovery stops discovery and starts it again if it is wanted. This
+// clears a discovery session that BlueZ reports as running while no
+// advertisements arrive anymore.
+func (a *Adapter) restartDiscovery() error {
+	c := &a.discovery
+	c.reconcileLock.Lock()
+	err := a.proxy().StopDiscovery()
+	if err != nil {
+		log.Println("TinyGo StopDiscovery", err)
+	}
+	c.lock.Lock()
+	c.started = false
+	c.lock.Unlock()
+	c.reconcileLock.Unlock()
+	return a.reconcileDiscovery()
+}
+
+// retryDiscovery reconciles again after a while, when starting discovery
+// failed.
+func (a *Adapter) retryDiscovery() {
+	c := &a.discovery
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.retry != nil {
+		c.retry.Stop()
+	}
+	c.retry = time.AfterFunc(time.Second, func() {
+		a.reconcileDiscovery()
+	})
+}
+
+// watchDiscovering starts watching the Discovering property of the adapter,
+// so that discovery is restarted when it was stopped by something else. The
+// watch runs until closeDiscovery.
+func (a *Adapter) watchDiscovering() {
+	c := &a.discovery
+	c.lock.Lock()
+	if c.watching {
+		c.lock.Unlock()
+		return
+	}
+	c.watching = true
+	c.lock.Unlock()
+
+	path := a.proxy().Path()
+	signal, cancel, err := watchPropertiesChanged(path)
+	if err != nil {
+		log.Println("TinyGo watch Discovering", err)
+		c.lock.Lock()
+		c.watching = false
+		c.lock.Unlock()
+		return
+	}
+	c.lock.Lock()
+	c.unwatch = cancel
+	c.lock.Unlock()
+	go func() {
+		defer cancel()
+		for sig := range signal {
+			changes, ok := propertiesChangedFor(sig, path, "org.bluez.Adapter1")
+			if !ok {
+				continue
+			}
+			val, ok := changes["Discovering"]
+			if !ok {
+				continue
+			}
+			discovering, _ := val.Value().(bool)
+			c.lock.Lock()
+			if c.state.Discovering != discovering {
+				c.state.Discovering = discovering
+				c.notify()
+			}
+			wanted := c.state.Wanted() && !c.closed
+			c.lock.Unlock()
+			if wanted != discovering {
+				go a.reconcileDiscovery()
+			}
+		}
+	}()
+}
+
+// closeDiscovery stops discovery for good, until the adapter is enabled
+// again, and stops the watcher of the Discovering property and the retry
+// timer. Requests and suspends that are still held may be released later.
+func (a *Adapter) closeDiscovery() error {
+	c := &a.discovery
+	c.lock.Lock()
+	if !c.closed && c.done != nil {
+		close(c.done)
+	}
+	c.closed = true
+	if c.retry != nil {
+		c.retry.Stop()
+		c.retry = nil
+	}
+	unwatch := c.unwatch
+	c.unwatch = nil
+	c.watching = false
+	c.lock.Unlock()
+	if unwatch != nil {
+		unwatch()
+	}
+	return a.reconcileDiscovery()
+}
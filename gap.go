@@ -2,6 +2,7 @@ package bluetooth
 
 import (
 	"errors"
+	"strings"
 	"time"
 )
 
@@ -9,6 +10,7 @@ var (
 	errScanning                  = errors.New("bluetooth: a scan is already in progress")
 	errNotScanning               = errors.New("bluetooth: there is no scan in progress")
 	errAdvertisementPacketTooBig = errors.New("bluetooth: advertisement packet overflows")
+	errInvalidAddressType        = errors.New("bluetooth: invalid address type")
 )
 
 // MACAddress contains a Bluetooth address which is a MAC address.
@@ -19,18 +21,42 @@ type MACAddress struct {
 	isRandom bool
 }
 
+// ParseAddress parses an address in 11:22:33:AA:BB:CC format, optionally
+// followed by the address type as "/public" or "/random". An address without
+// a type is a public address. It accepts everything returned by
+// MACAddress.MarshalText.
+func ParseAddress(s string) (MACAddress, error) {
+	var address MACAddress
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		switch s[i+1:] {
+		case "public":
+		case "random":
+			address.isRandom = true
+		default:
+			return MACAddress{}, errInvalidAddressType
+		}
+		s = s[:i]
+	}
+	mac, err := ParseMAC(s)
+	if err != nil {
+		return MACAddress{}, err
+	}
+	address.MAC = mac
+	return address, nil
+}
+
 // IsRandom if the address is randomly created.
 func (mac MACAddress) IsRandom() bool {
 	return mac.isRandom
 }
 
 // SetRandom if is a random address.
-func (mac MACAddress) SetRandom(val bool) {
+func (mac *MACAddress) SetRandom(val bool) {
 	mac.isRandom = val
 }
 
-// Set the address
-func (mac MACAddress) Set(val string) {
+// Set the address. The address is left unchanged if val cannot be parsed.
+func (mac *MACAddress) Set(val string) {
 	m, err := ParseMAC(val)
 	if err != nil {
 		return
@@ -39,6 +65,97 @@ func (mac MACAddress) Set(val string) {
 	mac.MAC = m
 }
 
+// Kind returns the kind of address, which for random addresses is derived
+// from the two most significant bits.
+func (mac MACAddress) Kind() AddressKind {
+	if !mac.isRandom {
+		return AddressPublic
+	}
+	switch mac.MAC[5] >> 6 {
+	case 0b11:
+		return AddressRandomStatic
+	case 0b01:
+		return AddressResolvablePrivate
+	case 0b00:
+		return AddressNonResolvablePrivate
+	default:
+		return AddressRandomReserved
+	}
+}
+
+// AddressType returns the address type as used by BlueZ and in ParseAddress,
+// either "public" or "random".
+func (mac MACAddress) AddressType() string {
+	if mac.isRandom {
+		return "random"
+	}
+	return "public"
+}
+
+// MarshalText returns the address in 11:22:33:AA:BB:CC format, followed by
+// "/random" for a random address. It implements encoding.TextMarshaler.
+func (mac MACAddress) MarshalText() ([]byte, error) {
+	buf := mac.MAC.AppendString(make([]byte, 0, 24))
+	if mac.isRandom {
+		buf = append(buf, "/random"...)
+	}
+	return buf, nil
+}
+
+// UnmarshalText parses an address as ParseAddress does. It implements
+// encoding.TextUnmarshaler.
+func (mac *MACAddress) UnmarshalText(text []byte) error {
+	address, err := ParseAddress(string(text))
+	if err != nil {
+		return err
+	}
+	*mac = address
+	return nil
+}
+
+// AddressKind classifies a Bluetooth LE address.
+type AddressKind uint8
+
+const (
+	// AddressPublic is an IEEE-assigned address.
+	AddressPublic AddressKind = iota
+
+	// AddressRandomStatic is a random address that stays the same at least
+	// until the device is power cycled.
+	AddressRandomStatic
+
+	// AddressResolvablePrivate is a random address that changes regularly
+	// but can be resolved to the identity of the device with its identity
+	// resolving key (IRK).
+	AddressResolvablePrivate
+
+	// AddressNonResolvablePrivate is a random address that changes regularly
+	// and cannot be resolved.
+	AddressNonResolvablePrivate
+
+	// AddressRandomReserved is a random address with the most significant
+	// bits set to the reserved value 0b10.
+	AddressRandomReserved
+)
+
+// String returns a short description of the address kind.
+func (k AddressKind) String() string {
+	switch k {
+	case AddressPublic:
+		return "public"
+	case AddressRandomStatic:
+		return "random static"
+	case AddressResolvablePrivate:
+		return "resolvable private"
+	case AddressNonResolvablePrivate:
+		return "non-resolvable private"
+	case AddressRandomReserved:
+		return "random reserved"
+	default:
+		return "unknown"
+	}
+}
+
 // AdvertisementOptions configures an advertisement instance. More options may
 // be added over time.
 type AdvertisementOptions struct {
@@ -51,6 +168,13 @@ type AdvertisementOptions struct {
 	// 128-bit UUIDs".
 	ServiceUUIDs []UUID
 
+	// ManufacturerData is the manufacturer specific data to advertise, keyed
+	// by company identifier.
+	ManufacturerData map[uint16][]byte
+
+	// ServiceData is the service data to advertise, keyed by service UUID.
+	ServiceData map[UUID][]byte
+
 	// Interval in BLE-specific units. Create an interval by using NewDuration.
 	Interval Duration
 }
@@ -75,9 +199,6 @@ type Addresser interface {
 	// String of the address
 	String() string
 
-	// Set the address
-	Set(val string)
-
 	// Is this address a random address?
 	// Bluetooth addresses are roughly split in two kinds: public
 	// (IEEE-assigned) addresses and random (not IEEE assigned) addresses.
@@ -85,9 +206,11 @@ type Addresser interface {
 	// random. Sometimes, it contains a hash.
 	// For more information:
 	// https://www.novelbits.io/bluetooth-address-privacy-ble/
-	// Set the address
-	SetRandom(bool)
 	IsRandom() bool
+
+	// Kind tells a public address apart from the different kinds of random
+	// addresses.
+	Kind() AddressKind
 }
 
 // ScanResult contains information from when an advertisement packet was
@@ -97,6 +220,11 @@ type ScanResult struct {
 	Address     Addresser
 	MUKAAddress string
 
+	// Identity is the identity address of the device if Address is a
+	// resolvable private address that was resolved with a Resolver, and nil
+	// otherwise.
+	Identity Addresser
+
 	// RSSI the last time a packet from this device has been received.
 	RSSI int16
 
@@ -127,6 +255,19 @@ type AdvertisementPayload interface {
 	// Bytes returns the raw advertisement packet, if available. It returns nil
 	// if this data is not available.
 	Bytes() []byte
+
+	// ManufacturerData returns the manufacturer specific data, keyed by the
+	// company identifier assigned by the Bluetooth SIG. It returns nil if no
+	// manufacturer data is present.
+	ManufacturerData() map[uint16][]byte
+
+	// ServiceData returns the service data, keyed by service UUID. It returns
+	// nil if no service data is present.
+	ServiceData() map[UUID][]byte
+
+	// TxPower returns the transmit power level in dBm. The second return
+	// value is false if the device does not advertise its transmit power.
+	TxPower() (int16, bool)
 }
 
 // AdvertisementFields contains advertisement fields in structured form.
@@ -139,6 +280,18 @@ type AdvertisementFields struct {
 	// part of the advertisement packet, in data types such as "complete list of
 	// 128-bit UUIDs".
 	ServiceUUIDs []UUID
+
+	// ManufacturerData is the manufacturer specific data, keyed by company
+	// identifier.
+	ManufacturerData map[uint16][]byte
+
+	// ServiceData is the service data, keyed by service UUID.
+	ServiceData map[UUID][]byte
+
+	// TxPower is the advertised transmit power level in dBm. It is only valid
+	// if HasTxPower is set.
+	TxPower    int16
+	HasTxPower bool
 }
 
 // advertisementFields wraps AdvertisementFields to implement the
@@ -171,6 +324,21 @@ func (p *advertisementFields) Bytes() []byte {
 	return nil
 }
 
+// ManufacturerData returns the underlying ManufacturerData field.
+func (p *advertisementFields) ManufacturerData() map[uint16][]byte {
+	return p.AdvertisementFields.ManufacturerData
+}
+
+// ServiceData returns the underlying ServiceData field.
+func (p *advertisementFields) ServiceData() map[UUID][]byte {
+	return p.AdvertisementFields.ServiceData
+}
+
+// TxPower returns the underlying TxPower field.
+func (p *advertisementFields) TxPower() (int16, bool) {
+	return p.AdvertisementFields.TxPower, p.AdvertisementFields.HasTxPower
+}
+
 // rawAdvertisementPayload encapsulates a raw advertisement packet. Methods to
 // get the data (such as LocalName()) will parse just the needed field. Scanning
 // the data should be fast as most advertisement packets only have a very small
@@ -219,6 +387,82 @@ func (buf *rawAdvertisementPayload) LocalName() string {
 	return ""
 }
 
+// ManufacturerData returns the manufacturer specific data fields in the
+// advertisement payload.
+func (buf *rawAdvertisementPayload) ManufacturerData() map[uint16][]byte {
+	var manufacturerData map[uint16][]byte
+	data := buf.Bytes()
+	for len(data) >= 2 {
+		fieldLength := data[0]
+		if fieldLength == 0 || int(fieldLength)+1 > len(data) {
+			break
+		}
+		if data[1] == 0xff && fieldLength >= 3 { // Manufacturer Specific Data
+			if manufacturerData == nil {
+				manufacturerData = make(map[uint16][]byte)
+			}
+			companyID := uint16(data[2]) | uint16(data[3])<<8
+			manufacturerData[companyID] = data[4 : fieldLength+1]
+		}
+		data = data[fieldLength+1:]
+	}
+	return manufacturerData
+}
+
+// ServiceData returns the service data fields in the advertisement payload,
+// for 16-bit, 32-bit and 128-bit service UUIDs.
+func (buf *rawAdvertisementPayload) ServiceData() map[UUID][]byte {
+	var serviceData map[UUID][]byte
+	data := buf.Bytes()
+	for len(data) >= 2 {
+		fieldLength := data[0]
+		if fieldLength == 0 || int(fieldLength)+1 > len(data) {
+			break
+		}
+		field := data[2 : fieldLength+1]
+		var uuid UUID
+		var size int
+		switch data[1] {
+		case 0x16: // Service Data - 16-bit UUID
+			size = 2
+		case 0x20: // Service Data - 32-bit UUID
+			size = 4
+		case 0x21: // Service Data - 128-bit UUID
+			size = 16
+		}
+		if size != 0 && len(field) >= size {
+			if size == 16 {
+				var b [16]byte
+				for i := range b {
+					b[i] = field[15-i]
+				}
+				uuid = NewUUID(b)
+			} else {
+				uuid = New16BitUUID(0)
+				uuid[3] = uint32(field[0]) | uint32(field[1])<<8
+				if size == 4 {
+					uuid[3] |= uint32(field[2])<<16 | uint32(field[3])<<24
+				}
+			}
+			if serviceData == nil {
+				serviceData = make(map[UUID][]byte)
+			}
+			serviceData[uuid] = field[size:]
+		}
+		data = data[fieldLength+1:]
+	}
+	return serviceData
+}
+
+// TxPower returns the Tx Power Level field in the advertisement payload.
+func (buf *rawAdvertisementPayload) TxPower() (int16, bool) {
+	b := buf.findField(0x0a) // Tx Power Level
+	if len(b) != 1 {
+		return 0, false
+	}
+	return int16(int8(b[0])), true
+}
+
 // HasServiceUUID returns true whether the given UUID is present in the
 // advertisement payload as a Service Class UUID. It checks both 16-bit UUIDs
 // and 128-bit UUIDs.
@@ -286,6 +530,16 @@ func (buf *rawAdvertisementPayload) addFromOptions(options AdvertisementOptions)
 			return false
 		}
 	}
+	for companyID, data := range options.ManufacturerData {
+		if !buf.addManufacturerData(companyID, data) {
+			return false
+		}
+	}
+	for uuid, data := range options.ServiceData {
+		if !buf.addServiceData(uuid, data) {
+			return false
+		}
+	}
 	return true
 }
 
@@ -347,6 +601,49 @@ func (buf *rawAdvertisementPayload) addServiceUUID(uuid UUID) (ok bool) {
 	}
 }
 
+// addManufacturerData adds a Manufacturer Specific Data field. It returns true
+// on success (the data fits) and false on failure.
+func (buf *rawAdvertisementPayload) addManufacturerData(companyID uint16, data []byte) (ok bool) {
+	if int(buf.len)+len(data)+4 > len(buf.data) {
+		return false // data doesn't fit
+	}
+	buf.data[buf.len+0] = byte(len(data) + 3) // length of field, including type
+	buf.data[buf.len+1] = 0xff                // type, 0xff means Manufacturer Specific Data
+	buf.data[buf.len+2] = byte(companyID)
+	buf.data[buf.len+3] = byte(companyID >> 8)
+	copy(buf.data[buf.len+4:], data)
+	buf.len += byte(len(data) + 4)
+	return true
+}
+
+// addServiceData adds a Service Data field for a 16-bit or 128-bit service
+// UUID. It returns true on success (the data fits) and false on failure.
+func (buf *rawAdvertisementPayload) addServiceData(uuid UUID, data []byte) (ok bool) {
+	if uuid.Is16Bit() {
+		if int(buf.len)+len(data)+4 > len(buf.data) {
+			return false // data doesn't fit
+		}
+		shortUUID := uuid.Get16Bit()
+		buf.data[buf.len+0] = byte(len(data) + 3) // length of field, including type
+		buf.data[buf.len+1] = 0x16                // type, 0x16 means "Service Data - 16-bit UUID"
+		buf.data[buf.len+2] = byte(shortUUID)
+		buf.data[buf.len+3] = byte(shortUUID >> 8)
+		copy(buf.data[buf.len+4:], data)
+		buf.len += byte(len(data) + 4)
+		return true
+	}
+	if int(buf.len)+len(data)+18 > len(buf.data) {
+		return false // data doesn't fit
+	}
+	buf.data[buf.len+0] = byte(len(data) + 17) // length of field, including type
+	buf.data[buf.len+1] = 0x21                 // type, 0x21 means "Service Data - 128-bit UUID"
+	rawUUID := uuid.Bytes()
+	copy(buf.data[buf.len+2:], rawUUID[:])
+	copy(buf.data[buf.len+18:], data)
+	buf.len += byte(len(data) + 18)
+	return true
+}
+
 // ConnectionParams are used when connecting to a peripherals.
 type ConnectionParams struct {
 	// The timeout for the connection attempt. Not used during the rest of the
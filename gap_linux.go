@@ -4,10 +4,10 @@
 package bluetooth
 
 import (
+	"context"
 	"log"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/godbus/dbus/v5"
 	"github.com/muka/go-bluetooth/api"
@@ -25,11 +25,16 @@ type Advertisement struct {
 	adapter       *Adapter
 	advertisement *api.Advertisement
 	properties    *advertising.LEAdvertisement1Properties
+	lock          sync.Mutex // guards started and unexpose
+	started       bool
+	unexpose      func()
 }
 
 // DefaultAdvertisement returns the default advertisement instance but does not
 // configure it.
 func (a *Adapter) DefaultAdvertisement() *Advertisement {
+	a.advertisementLock.Lock()
+	defer a.advertisementLock.Unlock()
 	if a.defaultAdvertisement == nil {
 		a.defaultAdvertisement = &Advertisement{
 			adapter: a,
@@ -54,6 +59,18 @@ func (a *Advertisement) Configure(options AdvertisementOptions) error {
 	for _, uuid := range options.ServiceUUIDs {
 		a.properties.ServiceUUIDs = append(a.properties.ServiceUUIDs, uuid.String())
 	}
+	if len(options.ManufacturerData) != 0 {
+		a.properties.ManufacturerData = make(map[uint16]interface{}, len(options.ManufacturerData))
+		for companyID, data := range options.ManufacturerData {
+			a.properties.ManufacturerData[companyID] = data
+		}
+	}
+	if len(options.ServiceData) != 0 {
+		a.properties.ServiceData = make(map[string]interface{}, len(options.ServiceData))
+		for uuid, data := range options.ServiceData {
+			a.properties.ServiceData[uuid.String()] = data
+		}
+	}
 
 	return nil
 }
@@ -63,10 +80,29 @@ func (a *Advertisement) Start() error {
 	if a.advertisement != nil {
 		panic("todo: start advertisement a second time")
 	}
-	_, err := api.ExposeAdvertisement(a.adapter.id, a.properties, uint32(a.properties.Timeout))
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	err := a.expose()
+	if err != nil {
+		return err
+	}
+	a.started = true
+	return nil
+}
+
+// expose registers the advertisement with BlueZ. It is also used to register
+// it again after bluetoothd restarted. The lock must be held.
+func (a *Advertisement) expose() error {
+	if a.unexpose != nil {
+		// Remove the object of the previous registration from the bus.
+		a.unexpose()
+		a.unexpose = nil
+	}
+	unexpose, err := api.ExposeAdvertisement(a.adapter.id, a.properties, uint32(a.properties.Timeout))
 	if err != nil {
 		return err
 	}
+	a.unexpose = unexpose
 	return nil
 }
 
@@ -79,55 +115,47 @@ func (a *Advertisement) Start() error {
 // possible some events are missed and perhaps even possible that some events
 // are duplicated.
 func (a *Adapter) Scan(filter map[string]interface{}, callback func(*Adapter, ScanResult)) error {
-	if a.cancelChan != nil {
-		return errScanning
-	}
-
 	// Channel that will be closed when the scan is stopped.
 	// Detecting whether the scan is stopped can be done by doing a non-blocking
 	// read from it. If it succeeds, the scan is stopped.
-	cancelChan := make(chan struct{})
-	a.cancelChan = cancelChan
+	cancelChan, err := a.startScan()
+	if err != nil {
+		return err
+	}
+	defer a.endScan(cancelChan)
+
+	// Devices are reported once their name matches, which may be after the
+	// first advertisement.
+	nameFilter := a.scanNameFilter()
 
 	// This appears to be necessary to receive any BLE discovery results at all.
 	if filter != nil {
-		defer a.adapter.SetDiscoveryFilter(nil)
-		err := a.adapter.SetDiscoveryFilter(filter)
+		defer a.proxy().SetDiscoveryFilter(nil)
+		err := a.proxy().SetDiscoveryFilter(filter)
 		if err != nil {
 			return err
 		}
 	}
 
-	bus, err := dbus.SystemBus()
+	signal, cancel, err := a.watchDeviceSignals()
 	if err != nil {
 		return err
 	}
-
-	signal := make(chan *dbus.Signal)
-	bus.Signal(signal)
-	defer bus.RemoveSignal(signal)
-
-	propertiesChangedMatchOptions := []dbus.MatchOption{dbus.WithMatchInterface("org.freedesktop.DBus.Properties")}
-	bus.AddMatchSignal(propertiesChangedMatchOptions...)
-	defer bus.RemoveMatchSignal(propertiesChangedMatchOptions...)
-
-	newObjectMatchOptions := []dbus.MatchOption{dbus.WithMatchInterface("org.freedesktop.DBus.ObjectManager")}
-	bus.AddMatchSignal(newObjectMatchOptions...)
-	defer bus.RemoveMatchSignal(newObjectMatchOptions...)
+	defer cancel()
 
 	// Go through all connected devices and present the connected devices as
 	// scan results. Also save the properties so that the full list of
 	// properties is known on a PropertiesChanged signal. We can't present the
 	// list of cached devices as scan results as devices may be cached for a
 	// long time, long after they have moved out of range.
-	deviceList, err := a.adapter.GetDevices()
+	deviceList, err := a.proxy().GetDevices()
 	if err != nil {
 		return err
 	}
 	devices := make(map[dbus.ObjectPath]*device.Device1Properties)
 	for _, dev := range deviceList {
-		if dev.Properties.Connected {
-			callback(a, makeScanResult(dev.Properties))
+		if dev.Properties.Connected && nameFilter.Match(dev.Properties.Name) {
+			callback(a, a.makeScanResult(dev.Properties))
 			select {
 			case <-cancelChan:
 				return nil
@@ -138,10 +166,11 @@ func (a *Adapter) Scan(filter map[string]interface{}, callback func(*Adapter, Sc
 	}
 
 	// Instruct BlueZ to start discovering.
-	err = a.adapter.StartDiscovery()
+	release, err := a.RequestDiscovery()
 	if err != nil {
 		return err
 	}
+	defer release()
 
 	for {
 		// Check whether the scan is stopped. This is necessary to avoid a race
@@ -150,7 +179,6 @@ func (a *Adapter) Scan(filter map[string]interface{}, callback func(*Adapter, Sc
 		// StopScan is called).
 		select {
 		case <-cancelChan:
-			a.adapter.StopDiscovery()
 			return nil
 		default:
 		}
@@ -170,8 +198,11 @@ func (a *Adapter) Scan(filter map[string]interface{}, callback func(*Adapter, Sc
 				var props *device.Device1Properties
 				props, _ = props.FromDBusMap(rawprops)
 				devices[objectPath] = props
+				if !nameFilter.Match(props.Name) {
+					continue
+				}
 				//log.Printf("Scan InterfacesAdded : %v\r\n", makeScanResult(props).Address)
-				callback(a, makeScanResult(props))
+				callback(a, a.makeScanResult(props))
 			case "org.freedesktop.DBus.Properties.PropertiesChanged":
 				interfaceName := sig.Body[0].(string)
 				if interfaceName != "org.bluez.Device1" {
@@ -179,6 +210,9 @@ func (a *Adapter) Scan(filter map[string]interface{}, callback func(*Adapter, Sc
 				}
 				changes := sig.Body[1].(map[string]dbus.Variant)
 				props := devices[sig.Path]
+				if props == nil {
+					continue
+				}
 				for field, val := range changes {
 					switch field {
 					case "RSSI":
@@ -187,10 +221,19 @@ func (a *Adapter) Scan(filter map[string]interface{}, callback func(*Adapter, Sc
 						props.Name = val.Value().(string)
 					case "UUIDs":
 						props.UUIDs = val.Value().([]string)
+					case "ManufacturerData":
+						props.ManufacturerData = makeManufacturerDataProperty(val)
+					case "ServiceData":
+						props.ServiceData = makeServiceDataProperty(val)
+					case "TxPower":
+						props.TxPower = val.Value().(int16)
 					}
 				}
+				if !nameFilter.Match(props.Name) {
+					continue
+				}
 				//log.Printf("Scan PropertiesChanged : %v\r\n", makeScanResult(props).Address)
-				callback(a, makeScanResult(props))
+				callback(a, a.makeScanResult(props))
 
 			}
 		case <-cancelChan:
@@ -201,124 +244,71 @@ func (a *Adapter) Scan(filter map[string]interface{}, callback func(*Adapter, Sc
 	// unreachable
 }
 
-var discoverying bool = false
-var Chdiscovery = make(chan bool)
-
-func (a *Adapter) resetdiscoverying() {
-	log.Printf("TingGo discoverying set false\r\n")
-	discoverying = false
-}
-
-//永远在后台执行 永不退出
-//往这个通道发消息 6S以后就会开始发现
-func (a *Adapter) DelayDiscovery(start chan bool) {
-	DurationOfTime := time.Duration(6) * time.Second
-	f := func() {
-		if !discoverying {
-			log.Printf("TinyGo DelayDiscovery\r\n")
-			a.onDiscovery()
-		}
-	}
-	for {
-		select {
-		case <-start:
-			time.AfterFunc(DurationOfTime, f)
-		}
-	}
-}
-
-//放弃使用 过于暴力
-func (a *Adapter) delayDiscovery() {
-	for {
-		time.Sleep(time.Second * 9)
-		if !discoverying {
-			log.Printf("TinyGo delayDiscovery\r\n")
-			a.onDiscovery()
-		}
-	}
+// watchDeviceSignals subscribes to the signals that report new devices of
+// this adapter and property changes of its devices.
+func (a *Adapter) watchDeviceSignals() (<-chan *dbus.Signal, func(), error) {
+	path := a.proxy().Path()
+	return watchSignals(signalFilter{
+		Path:      path,
+		Namespace: true,
+		Interface: "org.freedesktop.DBus.Properties",
+		Member:    "PropertiesChanged",
+	}, signalFilter{
+		Path:      "/",
+		Interface: "org.freedesktop.DBus.ObjectManager",
+		Arg0Path:  path,
+	})
 }
 
-func (a *Adapter) onDiscovery() error {
-	if discoverying {
-		log.Println("TinyGo StartDiscovery NULL")
-		return nil
-	}
-
-	err := a.adapter.StartDiscovery()
-	if err != nil {
-		log.Println("TinyGo StartDiscovery", err)
-		return err
-	}
-	discoverying = true
-	return nil
-}
+// ScanPlus scans for devices, connects to every device it finds and calls the
+// callback once the services of a device are resolved. Devices that are not
+// connected, bonded or trusted are removed from the BlueZ cache when the scan
+// starts.
+//
+// Deprecated: use an AutoConnector, which makes the devices to connect to,
+// the number of connections and the retry interval configurable.
+func (a *Adapter) ScanPlus(filter map[string]interface{}, callback func(*Adapter, ScanResult)) error {
 
-func (a *Adapter) offDiscovery() error {
-	if !discoverying {
-		log.Println("TinyGo StopDiscovery NULL")
-		return nil
-	}
-	err := a.adapter.StopDiscovery()
+	cancelChan, err := a.startScan()
 	if err != nil {
-		log.Println("TinyGo StopDiscovery", err)
 		return err
 	}
-	discoverying = false
-	return nil
-}
-
-var setOnce bool = true
-
-func (a *Adapter) ScanPlus(filter map[string]interface{}, callback func(*Adapter, ScanResult)) error {
-
-	if a.cancelChan != nil {
-		return errScanning
-	}
-
-	cancelChan := make(chan struct{})
-	a.cancelChan = cancelChan
+	defer a.endScan(cancelChan)
 
 	thisdevice := make(map[dbus.ObjectPath]*device.Device1Properties)
+	nameFilter := a.scanNameFilter()
 
-	if setOnce {
-		err := a.adapter.SetDiscoveryFilter(filter)
+	a.scanLock.Lock()
+	filterSet := a.scanFilterSet
+	a.scanLock.Unlock()
+	if !filterSet {
+		err := a.proxy().SetDiscoveryFilter(filter)
 		if err != nil {
 			return err
 		}
-		setOnce = false
-		//go a.delayDiscovery()
-		go a.DelayDiscovery(Chdiscovery)
+		a.scanLock.Lock()
+		a.scanFilterSet = true
+		a.scanLock.Unlock()
 	}
 
-	bus, err := dbus.SystemBus()
+	signal, cancel, err := a.watchDeviceSignals()
 	if err != nil {
 		return err
 	}
-
-	signal := make(chan *dbus.Signal)
-	bus.Signal(signal)
-	defer bus.RemoveSignal(signal)
-
-	propertiesChangedMatchOptions := []dbus.MatchOption{dbus.WithMatchInterface("org.freedesktop.DBus.Properties")}
-	bus.AddMatchSignal(propertiesChangedMatchOptions...)
-	defer bus.RemoveMatchSignal(propertiesChangedMatchOptions...)
-
-	newObjectMatchOptions := []dbus.MatchOption{dbus.WithMatchInterface("org.freedesktop.DBus.ObjectManager")}
-	bus.AddMatchSignal(newObjectMatchOptions...)
-	defer bus.RemoveMatchSignal(newObjectMatchOptions...)
+	defer cancel()
 
 	///////////////////start
-	deviceList, err := a.adapter.GetDevices()
+	deviceList, err := a.proxy().GetDevices()
 	if err != nil {
 		log.Printf("TingGo income die\r\n")
 		return err
 	}
 
 	for k, dev := range deviceList {
-		if dev.Properties.Connected {
+		if isProtected(dev) {
 			log.Printf("TingGo income %d-%s\r\n", k, dev.Properties.Address)
 		} else {
-			a.adapter.RemoveDevice(dev.Path()) //func (a *Adapter1) FlushDevices()
+			a.proxy().RemoveDevice(dev.Path()) //func (a *Adapter1) FlushDevices()
 			log.Printf("TingGo remove %d-%s\r\n", k, dev.Properties.Address)
 		}
 		thisdevice[dev.Path()] = dev.Properties
@@ -326,16 +316,16 @@ func (a *Adapter) ScanPlus(filter map[string]interface{}, callback func(*Adapter
 
 	///////////////////end
 
-	err = a.onDiscovery()
+	release, err := a.RequestDiscovery()
 	if err != nil {
 		return err
 	}
+	defer release()
 
 	for {
 
 		select {
 		case <-cancelChan:
-			a.offDiscovery()
 			log.Printf("TingGo goodbye\r\n")
 			return nil
 
@@ -360,8 +350,9 @@ func (a *Adapter) ScanPlus(filter map[string]interface{}, callback func(*Adapter
 				//log.Printf("TingGo NEW Node props.Name [%s]\r\n", props.Name)
 				log.Printf("TingGo NEW Node props.Address [%s]\r\n", props.Address)
 
-				//go
-				a.MUKAConnect(props.Address)
+				if nameFilter.Match(props.Name) {
+					a.MUKAConnect(props.Address)
+				}
 
 				break
 			case "org.freedesktop.DBus.Properties.PropertiesChanged":
@@ -381,7 +372,7 @@ func (a *Adapter) ScanPlus(filter map[string]interface{}, callback func(*Adapter
 					case "RSSI":
 						props.RSSI = val.Value().(int16)
 						log.Printf("TingGo CHG props.RSSI [%v]\r\n", props.RSSI)
-						if !props.Connected {
+						if !props.Connected && nameFilter.Match(props.Name) {
 							log.Printf("TingGo CHG this gay need connect [%s]\r\n", props.Address)
 							//go
 							a.MUKAConnect(props.Address)
@@ -390,6 +381,10 @@ func (a *Adapter) ScanPlus(filter map[string]interface{}, callback func(*Adapter
 					case "Name":
 						props.Name = val.Value().(string)
 						log.Printf("TingGo CHG props.Name [%v]\r\n", props.Name)
+						if !props.Connected && nameFilter.Mode() != NameAny && nameFilter.Match(props.Name) {
+							// The name arrived after the first advertisement.
+							a.MUKAConnect(props.Address)
+						}
 						break
 					case "UUIDs":
 						props.UUIDs = val.Value().([]string)
@@ -405,9 +400,9 @@ func (a *Adapter) ScanPlus(filter map[string]interface{}, callback func(*Adapter
 						break
 					case "ServicesResolved":
 						props.ServicesResolved = val.Value().(bool)
-						if props.ServicesResolved == true {
+						if props.ServicesResolved == true && nameFilter.Match(props.Name) {
 							log.Printf("TingGo CHG ServicesResolved [%s]\r\n", props.Address)
-							callback(a, makeScanResult(props))
+							callback(a, a.makeScanResult(props))
 						} else if props.ServicesResolved == false {
 							log.Printf("TingGo CHG DisServicesResolved [%s]\r\n", props.Address)
 						}
@@ -424,6 +419,8 @@ func (a *Adapter) ScanPlus(filter map[string]interface{}, callback func(*Adapter
 // callback to stop the current scan. If no scan is in progress, an error will
 // be returned.
 func (a *Adapter) StopScan() error {
+	a.scanLock.Lock()
+	defer a.scanLock.Unlock()
 	if a.cancelChan == nil {
 		return errNotScanning
 	}
@@ -433,6 +430,45 @@ func (a *Adapter) StopScan() error {
 	return nil
 }
 
+// startScan marks the adapter as scanning and returns the channel that
+// StopScan closes, or errScanning if a scan is already running.
+func (a *Adapter) startScan() (chan struct{}, error) {
+	a.scanLock.Lock()
+	defer a.scanLock.Unlock()
+	if a.cancelChan != nil {
+		return nil, errScanning
+	}
+	a.cancelChan = make(chan struct{})
+	return a.cancelChan, nil
+}
+
+// endScan marks the scan with the given channel as stopped, unless StopScan
+// did so already. It makes sure a scan that ended with an error does not keep
+// the adapter in the scanning state.
+func (a *Adapter) endScan(cancelChan chan struct{}) {
+	a.scanLock.Lock()
+	defer a.scanLock.Unlock()
+	if a.cancelChan == cancelChan {
+		close(cancelChan)
+		a.cancelChan = nil
+	}
+}
+
+// scanning returns whether a scan is running.
+func (a *Adapter) scanning() bool {
+	a.scanLock.Lock()
+	defer a.scanLock.Unlock()
+	return a.cancelChan != nil
+}
+
+// advertisement returns the default advertisement, or nil if it was never
+// requested.
+func (a *Adapter) advertisement() *Advertisement {
+	a.advertisementLock.Lock()
+	defer a.advertisementLock.Unlock()
+	return a.defaultAdvertisement
+}
+
 // makeScanResult creates a ScanResult from a Device1 object.
 func makeScanResult(props *device.Device1Properties) ScanResult {
 	// Assume the Address property is well-formed.
@@ -455,17 +491,100 @@ func makeScanResult(props *device.Device1Properties) ScanResult {
 		MUKAAddress: props.Address,
 		AdvertisementPayload: &advertisementFields{
 			AdvertisementFields{
-				LocalName:    props.Name,
-				ServiceUUIDs: serviceUUIDs,
+				LocalName:        props.Name,
+				ServiceUUIDs:     serviceUUIDs,
+				ManufacturerData: makeManufacturerData(props.ManufacturerData),
+				ServiceData:      makeServiceData(props.ServiceData),
+				// BlueZ omits TxPower if it is not advertised, which leaves
+				// it at 0 here, so 0 dBm is treated as not advertised.
+				TxPower:    props.TxPower,
+				HasTxPower: props.TxPower != 0,
 			},
 		},
 	}
 }
 
+// makeManufacturerDataProperty converts a changed ManufacturerData property
+// into the representation used by Device1Properties.
+func makeManufacturerDataProperty(val dbus.Variant) map[uint16]interface{} {
+	data := make(map[uint16]interface{})
+	switch v := val.Value().(type) {
+	case map[uint16]dbus.Variant:
+		for companyID, value := range v {
+			data[companyID] = value.Value()
+		}
+	case map[uint16]interface{}:
+		for companyID, value := range v {
+			data[companyID] = value
+		}
+	}
+	return data
+}
+
+// makeManufacturerData converts the ManufacturerData property of a Device1
+// object. The values are either plain byte slices or variants containing them,
+// depending on how the properties were obtained.
+func makeManufacturerData(data map[uint16]interface{}) map[uint16][]byte {
+	if len(data) == 0 {
+		return nil
+	}
+	manufacturerData := make(map[uint16][]byte, len(data))
+	for companyID, value := range data {
+		if v, ok := value.(dbus.Variant); ok {
+			value = v.Value()
+		}
+		if b, ok := value.([]byte); ok {
+			manufacturerData[companyID] = b
+		}
+	}
+	return manufacturerData
+}
+
+// makeServiceDataProperty converts the value of a ServiceData property in a
+// PropertiesChanged signal to the type used in Device1Properties.
+func makeServiceDataProperty(val dbus.Variant) map[string]interface{} {
+	data := make(map[string]interface{})
+	switch v := val.Value().(type) {
+	case map[string]dbus.Variant:
+		for uuid, value := range v {
+			data[uuid] = value.Value()
+		}
+	case map[string]interface{}:
+		for uuid, value := range v {
+			data[uuid] = value
+		}
+	}
+	return data
+}
+
+// makeServiceData converts the ServiceData property of a Device1 object.
+// Entries with a malformed UUID are skipped.
+func makeServiceData(data map[string]interface{}) map[UUID][]byte {
+	if len(data) == 0 {
+		return nil
+	}
+	serviceData := make(map[UUID][]byte, len(data))
+	for s, value := range data {
+		uuid, err := ParseUUID(s)
+		if err != nil {
+			continue
+		}
+		if v, ok := value.(dbus.Variant); ok {
+			value = v.Value()
+		}
+		if b, ok := value.([]byte); ok {
+			serviceData[uuid] = b
+		}
+	}
+	return serviceData
+}
+
 //全部冲洗 树干净 所以的连接的 都冲洗走
+//
+// Flush also removes bonded devices and their keys, use FlushUnbonded to keep
+// them.
 func (a *Adapter) Flush() (err error) {
-	defer a.resetdiscoverying()
-	devices, err := a.adapter.GetDevices()
+	devices, err := a.proxy().GetDevices()
 	if err != nil {
 		return err
 	}
@@ -477,10 +596,10 @@ func (a *Adapter) Flush() (err error) {
 	for i, dev := range devices {
 		log.Println("TingGo FlushDevices", i, dev.Path(), dev.Properties.Connected)
 		//if !dev.Properties.Connected {
-		err = a.adapter.RemoveDevice(dev.Path())
+		err = a.proxy().RemoveDevice(dev.Path())
 		//fmt.Println("REMOVE", dev.Path())
 		//}
-		//err = a.adapter.RemoveDevice(dev.Path())
+		//err = a.proxy().RemoveDevice(dev.Path())
 		if err != nil {
 			log.Println("TingGo FlushDevices Fail", i, dev.Path())
 			return err
@@ -494,7 +613,7 @@ func (a *Adapter) Flush() (err error) {
 
 //传入MAC地址 AA:BB:BB:BB:BB:BB 将其冲洗掉
 func (a *Adapter) FlushOne(address string) (err error) {
-	device, err := a.adapter.GetDeviceByAddress(address)
+	device, err := a.proxy().GetDeviceByAddress(address)
 	if err != nil {
 		return err
 	}
@@ -503,7 +622,7 @@ func (a *Adapter) FlushOne(address string) (err error) {
 		return nil
 	}
 
-	err = a.adapter.RemoveDevice(device.Path())
+	err = a.proxy().RemoveDevice(device.Path())
 	if err != nil {
 		log.Printf("TingGo FlushOne %s fail %v\r\n", device.Path(), err)
 		return err
@@ -516,6 +635,17 @@ func (a *Adapter) FlushOne(address string) (err error) {
 // Device is a connection to a remote peripheral.
 type Device struct {
 	device  *device.Device1
+	adapter *Adapter
+
+	disconnectedOnce sync.Once
+	disconnected     chan struct{}
+
+	// The bluetoothd process the device was obtained from.
+	stack stackHandle
+
+	// DevPath is the D-Bus object path of the device, such as
+	// /org/bluez/hci0/dev_11_22_33_AA_BB_CC. Use Address to get the
+	// Bluetooth address.
 	DevPath string
 }
 
@@ -550,10 +680,8 @@ func (a *Adapter) MUKAConnect(address string) *device.Device1 {
 	defer mapdel(address)
 
 	log.Printf("TingGo ==>Connect==>start %s\r\n", address)
-	a.offDiscovery()
-	//defer a.onDiscovery()
-	Chdiscovery <- true
-	dev, err := a.adapter.GetDeviceByAddress(address)
+	defer a.SuspendDiscovery()()
+	dev, err := a.proxy().GetDeviceByAddress(address)
 	if err != nil {
 		log.Printf("TingGo MUKAConnect GetDeviceByAddress ERR1 %v\r\n", err)
 		log.Printf("TingGo ==>Connect==>end1 %s\r\n", address)
@@ -565,12 +693,6 @@ func (a *Adapter) MUKAConnect(address string) *device.Device1 {
 		return nil
 	}
 
-	err = dev.SetTrusted(true)
-	if err != nil {
-		log.Printf("TingGo MUKAConnect SetTrusted ERR:%v\r\n", err)
-		log.Printf("TingGo ==>Connect==>end3 %s\r\n", address)
-		return nil
-	}
 	retry := 0
 LOOP:
 	err = dev.Connect()
@@ -586,6 +708,14 @@ LOOP:
 	}
 	log.Printf("TingGo==>Connect==>end5 %s\r\n", address)
 	log.Printf("TingGo MUKAConnect Connect OK %s\r\n", address)
+	d := &Device{
+		device:  dev,
+		adapter: a,
+		DevPath: string(dev.Path()),
+		stack:   currentStack(),
+	}
+	a.trackConnection(d)
+	d.Disconnected()
 	return dev
 }
 
@@ -600,22 +730,57 @@ func String_rm_char(a string, b string) string {
 
 // Connect starts a connection attempt to the given peripheral device address.
 //
-// On Linux and Windows, the IsRandom part of the address is ignored.
+// If BlueZ does not know the device yet, for example because it was never
+// scanned, the address type (public or random) is used to create it.
 func (a *Adapter) Connect(address Addresser, params ConnectionParams) (*Device, error) {
-	adr := address.(Address)
-	path := string(a.adapter.Path()) + "/dev_" + strings.Replace(adr.MAC.String(), ":", "_", -1)
+	return a.ConnectContext(context.Background(), address, params)
+}
+
+// ConnectContext is like Connect, but cancels the connection attempt when the
+// context is done.
+func (a *Adapter) ConnectContext(ctx context.Context, address Addresser, params ConnectionParams) (*Device, error) {
+	adr, ok := makeAddress(address)
+	if !ok {
+		return nil, errInvalidAddressType
+	}
+	path := string(a.proxy().Path()) + "/dev_" + strings.Replace(adr.MAC.String(), ":", "_", -1)
 	devicePath := dbus.ObjectPath(path)
+	if !a.hasDevice(devicePath) {
+		// ConnectDevice is marked experimental in BlueZ and needs bluetoothd
+		// to run with --experimental.
+		_, err := a.proxy().ConnectDevice(map[string]interface{}{
+			"Address":     adr.MAC.String(),
+			"AddressType": adr.AddressType(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 	dev, err := device.NewDevice1(devicePath) //device来自MUKA包//MUKA自己也封装了一个类似函数
 	if err != nil {
 		return nil, err
 	}
 
+	// Connecting is much more reliable while the radio is not scanning.
+	defer a.SuspendDiscovery()()
+
 	log.Printf("TingGo==>dev.Properties.Connected=%v\r\n", dev.Properties.Connected)
 	if !dev.Properties.Connected {
 		// Not yet connected, so do it now.
 		// The properties have just been read so this is fresh data.
 
-		err := dev.Connect()
+		errc := make(chan error, 1)
+		go func() {
+			errc <- dev.Connect()
+		}()
+		var err error
+		select {
+		case err = <-errc:
+		case <-ctx.Done():
+			// Disconnect also cancels a connection attempt in progress.
+			dev.Disconnect()
+			return nil, ctx.Err()
+		}
 		log.Printf("TingGo==>dev.Properties.Connected==>dev.Connect()=%v\r\n", err)
 		if err != nil {
 			return nil, err
@@ -623,43 +788,81 @@ func (a *Adapter) Connect(address Addresser, params ConnectionParams) (*Device,
 	} else {
 		log.Printf("TingGo==>dev.Properties.Connected==>do nothing\r\n")
 	}
-	// TODO: a proper async callback.
-	a.connectHandler(nil, true)
-	return &Device{
+	a.connectHandler(adr, true)
+	d := &Device{
 		device:  dev,
+		adapter: a,
 		DevPath: path,
-	}, nil
+		stack:   currentStack(),
+	}
+	a.trackConnection(d)
+	// Start watching for the disconnect so the connect handler is called.
+	d.Disconnected()
+	return d, nil
+}
+
+// makeAddress returns the Address in an Addresser, which may be any of the
+// address types of this package.
+func makeAddress(address Addresser) (Address, bool) {
+	switch address := address.(type) {
+	case Address:
+		return address, true
+	case *Address:
+		return *address, true
+	case MACAddress:
+		return Address{address}, true
+	case *MACAddress:
+		return Address{*address}, true
+	}
+	return Address{}, false
+}
+
+// hasDevice returns whether BlueZ has a device object at the given path.
+func (a *Adapter) hasDevice(path dbus.ObjectPath) bool {
+	devices, err := a.proxy().GetDevices()
+	if err != nil {
+		// Let the connection attempt report the error.
+		return true
+	}
+	for _, dev := range devices {
+		if dev.Path() == path {
+			return true
+		}
+	}
+	return false
 }
 
+// MUKAGetDeviceByAddress returns the device with the given address, in
+// 11:22:33:AA:BB:CC format, from the BlueZ device cache.
 func (a *Adapter) MUKAGetDeviceByAddress(address string) (*Device, error) {
 
-	dev, err := a.adapter.GetDeviceByAddress(address)
+	dev, err := a.proxy().GetDeviceByAddress(address)
 	if err != nil {
 		return nil, err
 	}
+	if dev == nil {
+		return nil, errDeviceNotFound
+	}
 
 	return &Device{
 		device:  dev,
-		DevPath: address,
+		adapter: a,
+		DevPath: string(dev.Path()),
+		stack:   currentStack(),
 	}, nil
 }
 
 // Disconnect from the BLE device. This method is non-blocking and does not
-// wait until the connection is fully gone.
+// wait until the connection is fully gone. Use DisconnectContext to wait for
+// it, or Disconnected to be notified.
 func (d *Device) Disconnect() error {
-	//源码
+	if err := d.stack.check(); err != nil {
+		return err
+	}
 	return d.device.Disconnect()
-	//方式2 定位永不返回
-	log.Printf("TingGo==>Disconnect==>start\r\n")
-	d.device.Disconnect()
-	log.Printf("TingGo==>Disconnect==>end\r\n")
-	time.Sleep(time.Microsecond * 50)
-	return nil
-	//方式3
-	//d.device.Close()
-	//return nil
 }
 
+// IsConnected returns whether the device is currently connected.
 func (d *Device) IsConnected() bool {
 	b, e := d.device.GetConnected()
 	if e == nil {
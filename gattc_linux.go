@@ -4,8 +4,10 @@
 package bluetooth
 
 import (
+	"context"
 	"errors"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/muka/go-bluetooth/bluez"
@@ -21,6 +23,7 @@ type DeviceService struct {
 	uuidWrapper
 
 	service *gatt.GattService1
+	device  *Device
 }
 
 // UUID returns the UUID for this DeviceService.
@@ -39,21 +42,24 @@ func (s *DeviceService) UUID() UUID {
 // On Linux with BlueZ, this just waits for the ServicesResolved signal (if
 // services haven't been resolved yet) and uses this list of cached services.
 func (d *Device) DiscoverServices(uuids []UUID) ([]DeviceService, error) {
-	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	services, err := d.DiscoverServicesContext(ctx, uuids)
+	if err == context.DeadlineExceeded {
+		return nil, errors.New("timeout on DiscoverServices")
+	}
+	return services, err
+}
 
-	for {
-		resolved, err := d.device.GetServicesResolved()
-		if err != nil {
-			return nil, err
-		}
-		if resolved {
-			break
-		}
-		// This is a terrible hack, but I couldn't find another way.
-		time.Sleep(10 * time.Millisecond)
-		if time.Since(start) > 10*time.Second {
-			return nil, errors.New("timeout on DiscoverServices")
-		}
+// DiscoverServicesContext is like DiscoverServices, but waits for the
+// services to be resolved no longer than the context allows.
+func (d *Device) DiscoverServicesContext(ctx context.Context, uuids []UUID) ([]DeviceService, error) {
+	if err := d.stack.check(); err != nil {
+		return nil, err
+	}
+	err := d.waitServicesResolved(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	services := []DeviceService{}
@@ -106,6 +112,7 @@ func (d *Device) DiscoverServices(uuids []UUID) ([]DeviceService, error) {
 		uuid, _ := ParseUUID(service.Properties.UUID)
 		ds := DeviceService{uuidWrapper: uuid,
 			service: service,
+			device:  d,
 		}
 
 		services = append(services, ds)
@@ -126,6 +133,7 @@ type DeviceCharacteristic struct {
 	uuidWrapper
 
 	characteristic *gatt.GattCharacteristic1
+	device         *Device
 }
 
 // UUID returns the UUID for this DeviceCharacteristic.
@@ -193,6 +201,7 @@ func (s *DeviceService) DiscoverCharacteristics(uuids []UUID) ([]DeviceCharacter
 		uuid, _ := ParseUUID(char.Properties.UUID)
 		dc := DeviceCharacteristic{uuidWrapper: uuid,
 			characteristic: char,
+			device:         s.device,
 		}
 
 		chars = append(chars, dc)
@@ -207,46 +216,164 @@ func (s *DeviceService) DiscoverCharacteristics(uuids []UUID) ([]DeviceCharacter
 	return chars, nil
 }
 
+// GATTStats returns the statistics of the GATT operation queue of this device.
+// The queue is shared by all Device values of the same device.
+func (d *Device) GATTStats() GATTStats {
+	return gattQueues.stats(string(d.device.Path()))
+}
+
+// do runs a GATT operation through the queue of the device this
+// characteristic belongs to. Operations on the same device never run
+// concurrently, even through different Device values, so the methods of
+// DeviceCharacteristic can be called from any goroutine.
+func (c DeviceCharacteristic) do(ctx context.Context, opts []GATTOption, fn func() error) error {
+	if c.device == nil {
+		return fn()
+	}
+	if err := c.device.stack.check(); err != nil {
+		return err
+	}
+	q := gattQueues.get(string(c.device.device.Path()))
+	defer gattQueues.put(q)
+	return q.do(ctx, opts, fn)
+}
+
+// defaultContext returns the context used by the GATT methods that do not
+// take one.
+func defaultContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), defaultGATTTimeout)
+}
+
 // WriteWithoutResponse replaces the characteristic value with a new value. The
 // call will return before all data has been written. A limited number of such
 // writes can be in flight at any given time. This call is also known as a
 // "write command" (as opposed to a write request).
 func (c DeviceCharacteristic) WriteWithoutResponse(p []byte) (n int, err error) {
-	err = c.characteristic.WriteValue(p, nil)
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return c.WriteWithoutResponseContext(ctx, p)
+}
+
+// WriteWithoutResponseContext is like WriteWithoutResponse, but waits in the
+// GATT queue of the device no longer than the context allows.
+func (c DeviceCharacteristic) WriteWithoutResponseContext(ctx context.Context, p []byte, opts ...GATTOption) (n int, err error) {
+	err = c.do(ctx, opts, func() error {
+		return c.characteristic.WriteValue(p, nil)
+	})
 	if err != nil {
 		return 0, err
 	}
 	return len(p), nil
 }
 
+var errNotificationsNotEnabled = errors.New("bluetooth: notifications are not enabled for this channel")
+
+// notifications holds the subscriptions of the channels returned by
+// EnableNotifications, so that DisableNotifications and Adapter.Close can
+// remove them.
+var notifications = struct {
+	lock sync.Mutex
+	subs map[chan *bluez.PropertyChanged]notification
+}{
+	subs: make(map[chan *bluez.PropertyChanged]notification),
+}
+
+// notificationBuffer is the number of property changes the channel returned
+// by EnableNotifications holds.
+const notificationBuffer = 16
+
+// notification is a subscription made by EnableNotifications.
+type notification struct {
+	char   DeviceCharacteristic
+	cancel func()
+}
+
 // EnableNotifications enables notifications in the Client Characteristic
 // Configuration Descriptor (CCCD). This means that most peripherals will send a
 // notification with a new value every time the value of the characteristic
 // changes.
+//
+// The callback receives the new values. The returned channel receives every
+// property change of the characteristic, including the values, as long as it
+// is read; changes that arrive while its buffer is full are dropped, so it
+// need not be read at all. It is closed once DisableNotifications was called
+// with it. If enabling fails, nothing stays subscribed and the channel is nil.
 func (c DeviceCharacteristic) EnableNotifications(callback func(buf []byte)) (chan *bluez.PropertyChanged, error) {
-	ch, err := c.characteristic.WatchProperties()
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return c.EnableNotificationsContext(ctx, callback)
+}
+
+// EnableNotificationsContext is like EnableNotifications, but waits in the
+// GATT queue of the device no longer than the context allows. It runs with
+// PriorityHigh unless another priority is given.
+func (c DeviceCharacteristic) EnableNotificationsContext(ctx context.Context, callback func(buf []byte), opts ...GATTOption) (chan *bluez.PropertyChanged, error) {
+	path := c.characteristic.Path()
+	signal, cancel, err := watchPropertiesChanged(path)
 	if err != nil {
-		return ch, err
+		return nil, err
 	}
+	ch := make(chan *bluez.PropertyChanged, notificationBuffer)
+	notifications.lock.Lock()
+	notifications.subs[ch] = notification{c, cancel}
+	notifications.lock.Unlock()
 	go func() {
-		for update := range ch {
-			if update == nil {
-				break
+		// The signal channel is closed when the subscription is canceled.
+		defer close(ch)
+		for sig := range signal {
+			changes, ok := propertiesChangedFor(sig, path, "org.bluez.GattCharacteristic1")
+			if !ok {
+				continue
 			}
-			if update.Interface == "org.bluez.GattCharacteristic1" && update.Name == "Value" {
-				callback(update.Value.([]byte))
+			if val, ok := changes["Value"]; ok {
+				if buf, ok := val.Value().([]byte); ok {
+					callback(buf)
+				}
+			}
+			for name, val := range changes {
+				select {
+				case ch <- &bluez.PropertyChanged{Interface: "org.bluez.GattCharacteristic1", Name: name, Value: val.Value()}:
+				default:
+				}
 			}
 		}
 	}()
-	return ch, c.characteristic.StartNotify()
+	opts = append([]GATTOption{WithPriority(PriorityHigh)}, opts...)
+	err = c.do(ctx, opts, c.characteristic.StartNotify)
+	if err != nil {
+		notifications.lock.Lock()
+		delete(notifications.subs, ch)
+		notifications.lock.Unlock()
+		cancel()
+		return nil, err
+	}
+	return ch, nil
 }
+
+// DisableNotifications disables notifications that were enabled with
+// EnableNotifications. The channel returned by EnableNotifications must be
+// passed.
 func (c DeviceCharacteristic) DisableNotifications(ch chan *bluez.PropertyChanged) error {
-	err := c.characteristic.UnwatchProperties(ch)
-	if err != nil {
-		return err
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return c.DisableNotificationsContext(ctx, ch)
+}
+
+// DisableNotificationsContext is like DisableNotifications, but waits in the
+// GATT queue of the device no longer than the context allows. It runs with
+// PriorityHigh unless another priority is given.
+func (c DeviceCharacteristic) DisableNotificationsContext(ctx context.Context, ch chan *bluez.PropertyChanged, opts ...GATTOption) error {
+	notifications.lock.Lock()
+	n, ok := notifications.subs[ch]
+	delete(notifications.subs, ch)
+	notifications.lock.Unlock()
+	if !ok {
+		return errNotificationsNotEnabled
 	}
+	n.cancel()
 
-	err = c.characteristic.StopNotify()
+	opts = append([]GATTOption{WithPriority(PriorityHigh)}, opts...)
+	err := c.do(ctx, opts, c.characteristic.StopNotify)
 	if err != nil {
 		return err
 	}
@@ -256,8 +383,20 @@ func (c DeviceCharacteristic) DisableNotifications(ch chan *bluez.PropertyChange
 
 // Read reads the current characteristic value.
 func (c *DeviceCharacteristic) Read(data []byte) (int, error) {
-	options := make(map[string]interface{})
-	result, err := c.characteristic.ReadValue(options)
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return c.ReadContext(ctx, data)
+}
+
+// ReadContext is like Read, but waits in the GATT queue of the device no
+// longer than the context allows.
+func (c *DeviceCharacteristic) ReadContext(ctx context.Context, data []byte, opts ...GATTOption) (int, error) {
+	var result []byte
+	err := c.do(ctx, opts, func() (err error) {
+		options := make(map[string]interface{})
+		result, err = c.characteristic.ReadValue(options)
+		return err
+	})
 	if err != nil {
 		return 0, err
 	}
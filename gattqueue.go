@@ -0,0 +1,301 @@
+package bluetooth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// GATTPriority is the priority of a queued GATT operation. Operations with a
+// higher priority run before operations with a lower priority, operations
+// with the same priority run in order.
+type GATTPriority uint8
+
+const (
+	// PriorityLow is for background work, such as periodic reads.
+	PriorityLow GATTPriority = iota
+
+	// PriorityNormal is the default priority for reads and writes.
+	PriorityNormal
+
+	// PriorityHigh is the default priority for enabling and disabling
+	// notifications, so that setup is not delayed by a long queue of reads.
+	PriorityHigh
+
+	numGATTPriorities
+)
+
+// GATTOption configures a single GATT operation.
+type GATTOption func(*gattOptions)
+
+type gattOptions struct {
+	priority GATTPriority
+	timeout  time.Duration
+}
+
+// WithPriority sets the queue priority of a GATT operation.
+func WithPriority(priority GATTPriority) GATTOption {
+	return func(o *gattOptions) {
+		if priority >= numGATTPriorities {
+			priority = numGATTPriorities - 1
+		}
+		o.priority = priority
+	}
+}
+
+// WithTimeout limits the time a GATT operation may take, including the time
+// it waits in the queue. Zero means no timeout other than the context.
+func WithTimeout(timeout time.Duration) GATTOption {
+	return func(o *gattOptions) {
+		o.timeout = timeout
+	}
+}
+
+// defaultGATTTimeout is used by the GATT methods that do not take a context.
+const defaultGATTTimeout = 30 * time.Second
+
+// GATTStats contains counters of the GATT operation queue of a device.
+type GATTStats struct {
+	// Operations that have been queued in total.
+	Queued uint64
+
+	// Operations that ran, successfully or not. Operations that expired in
+	// the queue are not included.
+	Completed uint64
+
+	// Operations that returned an error. Timeouts are included.
+	Failed uint64
+
+	// Operations that were abandoned because their context was done. The
+	// operation itself may still have run to completion in the background.
+	// Expired operations are included.
+	TimedOut uint64
+
+	// Operations whose context was done while they were still waiting in the
+	// queue. They never ran.
+	Expired uint64
+
+	// Current and maximum number of operations waiting in the queue.
+	Pending    int
+	MaxPending int
+
+	// Total time spent waiting in the queue and running operations.
+	WaitTime time.Duration
+	RunTime  time.Duration
+}
+
+// gattQueue serializes GATT operations to a single device. BlueZ rejects
+// concurrent operations on a device with "Operation already in progress". The
+// zero value is ready to use.
+type gattQueue struct {
+	lock    sync.Mutex
+	busy    bool
+	pending [numGATTPriorities][]*gattWaiter
+	stats   GATTStats
+	users   int // operations using the queue, see gattQueueSet
+}
+
+// gattWaiter is an operation waiting for its turn.
+type gattWaiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// do runs fn once all earlier operations with the same or a higher priority
+// have finished. If the context is done before fn returns, do returns the
+// context error right away while fn keeps the queue until it returns, so that
+// the next operation does not collide with it in BlueZ.
+func (q *gattQueue) do(ctx context.Context, opts []GATTOption, fn func() error) error {
+	options := gattOptions{priority: PriorityNormal}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+
+	queued := time.Now()
+	err := q.acquire(ctx, options.priority)
+	if err != nil {
+		q.expire(time.Since(queued), err)
+		return err
+	}
+	started := time.Now()
+
+	result := make(chan error, 1)
+	go func() {
+		err := fn()
+		q.release()
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		q.finish(started.Sub(queued), time.Since(started), err, false)
+		return err
+	case <-ctx.Done():
+		q.finish(started.Sub(queued), time.Since(started), ctx.Err(), true)
+		return ctx.Err()
+	}
+}
+
+// acquire waits until the queue is free for an operation of the given
+// priority.
+func (q *gattQueue) acquire(ctx context.Context, priority GATTPriority) error {
+	q.lock.Lock()
+	q.stats.Queued++
+	if !q.busy {
+		q.busy = true
+		q.lock.Unlock()
+		return nil
+	}
+	w := &gattWaiter{ready: make(chan struct{})}
+	q.pending[priority] = append(q.pending[priority], w)
+	q.stats.Pending++
+	if q.stats.Pending > q.stats.MaxPending {
+		q.stats.MaxPending = q.stats.Pending
+	}
+	q.lock.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	q.lock.Lock()
+	if w.granted {
+		// The queue was handed over right as the context was done. Pass it
+		// on to the next operation.
+		q.lock.Unlock()
+		q.release()
+		return ctx.Err()
+	}
+	waiters := q.pending[priority]
+	for i := range waiters {
+		if waiters[i] == w {
+			q.pending[priority] = append(waiters[:i], waiters[i+1:]...)
+			q.stats.Pending--
+			break
+		}
+	}
+	q.lock.Unlock()
+	return ctx.Err()
+}
+
+// release hands the queue to the next waiting operation with the highest
+// priority, or marks it as free.
+func (q *gattQueue) release() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for priority := int(numGATTPriorities) - 1; priority >= 0; priority-- {
+		waiters := q.pending[priority]
+		if len(waiters) == 0 {
+			continue
+		}
+		w := waiters[0]
+		q.pending[priority] = waiters[1:]
+		q.stats.Pending--
+		w.granted = true
+		close(w.ready)
+		return
+	}
+	q.busy = false
+}
+
+// expire updates the statistics after an operation expired in the queue.
+func (q *gattQueue) expire(wait time.Duration, err error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.stats.Failed++
+	q.stats.TimedOut++
+	q.stats.Expired++
+	q.stats.WaitTime += wait
+}
+
+// finish updates the statistics after an operation ran.
+func (q *gattQueue) finish(wait, run time.Duration, err error, timedOut bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.stats.Completed++
+	if err != nil {
+		q.stats.Failed++
+	}
+	if timedOut {
+		q.stats.TimedOut++
+	}
+	q.stats.WaitTime += wait
+	q.stats.RunTime += run
+}
+
+// snapshot returns a copy of the queue statistics.
+func (q *gattQueue) snapshot() GATTStats {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.stats
+}
+
+// idle returns whether no operation is running or waiting.
+func (q *gattQueue) idle() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return !q.busy && q.stats.Pending == 0
+}
+
+// gattQueueSet holds one queue per device, so that every handle to the same
+// device shares the queue. Devices are identified by their object path.
+type gattQueueSet struct {
+	lock   sync.Mutex
+	queues map[string]*gattQueue
+}
+
+// gattQueues holds the queues of all devices.
+var gattQueues gattQueueSet
+
+// get returns the queue of the device and marks it as in use until put is
+// called.
+func (s *gattQueueSet) get(device string) *gattQueue {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.queues == nil {
+		s.queues = make(map[string]*gattQueue)
+	}
+	q := s.queues[device]
+	if q == nil {
+		q = &gattQueue{}
+		s.queues[device] = q
+	}
+	q.users++
+	return q
+}
+
+// put marks the queue as no longer used by an operation.
+func (s *gattQueueSet) put(q *gattQueue) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	q.users--
+}
+
+// drop removes the queue of a device that went away, unless it is still in
+// use. A later operation starts with a new queue.
+func (s *gattQueueSet) drop(device string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	q := s.queues[device]
+	if q != nil && q.users == 0 && q.idle() {
+		delete(s.queues, device)
+	}
+}
+
+// stats returns the statistics of the queue of a device.
+func (s *gattQueueSet) stats(device string) GATTStats {
+	s.lock.Lock()
+	q := s.queues[device]
+	s.lock.Unlock()
+	if q == nil {
+		return GATTStats{}
+	}
+	return q.snapshot()
+}
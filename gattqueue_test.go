@@ -0,0 +1,115 @@
+package bluetooth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGATTQueuePriority(t *testing.T) {
+	var q gattQueue
+	ctx := context.Background()
+
+	// Block the queue until the other operations are waiting.
+	unblock := make(chan struct{})
+	blocked := make(chan struct{})
+	go q.do(ctx, nil, func() error {
+		close(blocked)
+		<-unblock
+		return nil
+	})
+	<-blocked
+
+	order := make(chan GATTPriority, 3)
+	done := make(chan struct{}, 3)
+	for _, priority := range []GATTPriority{PriorityLow, PriorityNormal, PriorityHigh} {
+		priority := priority
+		go func() {
+			q.do(ctx, []GATTOption{WithPriority(priority)}, func() error {
+				order <- priority
+				return nil
+			})
+			done <- struct{}{}
+		}()
+	}
+	for q.snapshot().Pending != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	close(unblock)
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+
+	for _, expected := range []GATTPriority{PriorityHigh, PriorityNormal, PriorityLow} {
+		if got := <-order; got != expected {
+			t.Errorf("expected priority %d to run but got %d", expected, got)
+		}
+	}
+}
+
+func TestGATTQueueTimeout(t *testing.T) {
+	var q gattQueue
+	ctx := context.Background()
+
+	unblock := make(chan struct{})
+	blocked := make(chan struct{})
+	go q.do(ctx, nil, func() error {
+		close(blocked)
+		<-unblock
+		return nil
+	})
+	<-blocked
+
+	err := q.do(ctx, []GATTOption{WithTimeout(10 * time.Millisecond)}, func() error {
+		t.Error("operation ran while the queue was busy")
+		return nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded but got %v", err)
+	}
+	close(unblock)
+
+	// The queue must be usable again once the first operation finished.
+	err = q.do(ctx, []GATTOption{WithTimeout(time.Second)}, func() error { return nil })
+	if err != nil {
+		t.Errorf("expected nil but got %v", err)
+	}
+	if stats := q.snapshot(); stats.TimedOut != 1 || stats.Expired != 1 || stats.Pending != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	// The expired operation never ran, so it does not count as completed.
+	// The first operation finishes in the background.
+	deadline := time.Now().Add(time.Second)
+	for q.snapshot().Completed < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if stats := q.snapshot(); stats.Completed != 2 || stats.Queued != 3 {
+		t.Errorf("expected 2 of 3 operations completed, got %+v", stats)
+	}
+}
+
+func TestGATTQueueSet(t *testing.T) {
+	var s gattQueueSet
+	const device = "/org/bluez/hci0/dev_00_11_22_33_44_55"
+	q := s.get(device)
+	if s.get(device) != q {
+		t.Fatal("handles of the same device got different queues")
+	}
+	if s.get("/org/bluez/hci0/dev_00_11_22_33_44_66") == q {
+		t.Fatal("different devices share a queue")
+	}
+	s.put(q)
+
+	// Still used by one operation.
+	s.drop(device)
+	if s.get(device) != q {
+		t.Fatal("queue in use was dropped")
+	}
+	s.put(q)
+	s.put(q)
+
+	s.drop(device)
+	if s.get(device) == q {
+		t.Error("idle queue was not dropped")
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/godbus/dbus/v5 v5.0.3
 	github.com/muka/go-bluetooth v0.0.0-20210812063148-b6c83362e27d
 	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	gopkg.in/yaml.v3 v3.0.0-20200506231410-2ff61e1afc86
 	tinygo.org/x/bluetooth v0.5.0
 	tinygo.org/x/drivers v0.20.0
 	tinygo.org/x/tinyterm v0.1.0
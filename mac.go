@@ -9,7 +9,8 @@ var errInvalidMAC = errors.New("bluetooth: failed to parse MAC address errInvali
 var errInvalidIndex = errors.New("bluetooth: failed to parse MAC address errInvalidIndex")
 
 // ParseMAC parses the given MAC address, which must be in 11:22:33:AA:BB:CC
-// format. If it cannot be parsed, an error is returned.
+// format. Hex digits may be upper or lower case. If it cannot be parsed, an
+// error is returned.
 func ParseMAC(s string) (mac MAC, err error) {
 	macIndex := 11
 	for i := 0; i < len(s); i++ {
@@ -22,6 +23,8 @@ func ParseMAC(s string) (mac MAC, err error) {
 			nibble = c - '0' + 0x0
 		} else if c >= 'A' && c <= 'F' {
 			nibble = c - 'A' + 0xA
+		} else if c >= 'a' && c <= 'f' {
+			nibble = c - 'a' + 0xA
 		} else {
 			err = errInvalidMAC
 			return
@@ -47,31 +50,21 @@ func ParseMAC(s string) (mac MAC, err error) {
 // String returns a human-readable version of this MAC address, such as
 // 11:22:33:AA:BB:CC.
 func (mac MAC) String() string {
-	// TODO: make this more efficient.
-	s := ""
+	var buf [17]byte
+	return string(mac.AppendString(buf[:0]))
+}
+
+// AppendString appends the string form of this MAC address, as returned by
+// String, to buf and returns the extended buffer.
+func (mac MAC) AppendString(buf []byte) []byte {
+	const hexDigits = "0123456789ABCDEF"
 	for i := 5; i >= 0; i-- {
 		c := mac[i]
-		// Insert a hyphen at the correct locations.
+		// Insert a colon at the correct locations.
 		if i != 5 {
-			s += ":"
-		}
-
-		// First nibble.
-		nibble := c >> 4
-		if nibble <= 9 {
-			s += string(nibble + '0')
-		} else {
-			s += string(nibble + 'A' - 10)
-		}
-
-		// Second nibble.
-		nibble = c & 0x0f
-		if nibble <= 9 {
-			s += string(nibble + '0')
-		} else {
-			s += string(nibble + 'A' - 10)
+			buf = append(buf, ':')
 		}
+		buf = append(buf, hexDigits[c>>4], hexDigits[c&0x0f])
 	}
-
-	return s
+	return buf
 }
@@ -0,0 +1,82 @@
+package bluetooth
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMAC(t *testing.T) {
+	for _, s := range []string{"11:22:33:AA:BB:CC", "11:22:33:aa:bb:cc", "112233aAbBcC"} {
+		mac, err := ParseMAC(s)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", s, err)
+			continue
+		}
+		if mac != (MAC{0xcc, 0xbb, 0xaa, 0x33, 0x22, 0x11}) || mac.String() != "11:22:33:AA:BB:CC" {
+			t.Errorf("%s: parsed as %s", s, mac)
+		}
+	}
+	for _, s := range []string{"", "11:22:33:AA:BB", "11:22:33:AA:BB:CC:DD", "11:22:33:AA:BB:CG"} {
+		if _, err := ParseMAC(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestMACAddressSetters(t *testing.T) {
+	var address MACAddress
+	address.Set("11:22:33:aa:bb:cc")
+	address.SetRandom(true)
+	if address.String() != "11:22:33:AA:BB:CC" || !address.IsRandom() {
+		t.Errorf("setters had no effect: %s random=%v", address, address.IsRandom())
+	}
+	address.Set("invalid")
+	if address.String() != "11:22:33:AA:BB:CC" {
+		t.Errorf("invalid address changed the address to %s", address)
+	}
+}
+
+func TestAddressKind(t *testing.T) {
+	for _, tc := range []struct {
+		address string
+		kind    AddressKind
+	}{
+		{"C1:22:33:44:55:66", AddressPublic},
+		{"C1:22:33:44:55:66/public", AddressPublic},
+		{"C1:22:33:44:55:66/random", AddressRandomStatic},
+		{"4A:22:33:44:55:66/random", AddressResolvablePrivate},
+		{"3F:22:33:44:55:66/random", AddressNonResolvablePrivate},
+		{"80:22:33:44:55:66/random", AddressRandomReserved},
+	} {
+		address, err := ParseAddress(tc.address)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.address, err)
+			continue
+		}
+		if kind := address.Kind(); kind != tc.kind {
+			t.Errorf("%s: expected %s but got %s", tc.address, tc.kind, kind)
+		}
+	}
+	if _, err := ParseAddress("C1:22:33:44:55:66/static"); err != errInvalidAddressType {
+		t.Errorf("expected errInvalidAddressType but got %v", err)
+	}
+}
+
+func TestAddressMarshalText(t *testing.T) {
+	random, _ := ParseAddress("c1:22:33:44:55:66/random")
+	public, _ := ParseAddress("11:22:33:44:55:66")
+	data, err := json.Marshal([]MACAddress{random, public})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["C1:22:33:44:55:66/random","11:22:33:44:55:66"]`; string(data) != want {
+		t.Errorf("expected %s but got %s", want, data)
+	}
+	var addresses []MACAddress
+	if err := json.Unmarshal(data, &addresses); err != nil {
+		t.Fatal(err)
+	}
+	if len(addresses) != 2 || addresses[0] != random || addresses[1] != public {
+		t.Errorf("round trip gave %v", addresses)
+	}
+}
@@ -0,0 +1,133 @@
+package bluetooth
+
+// This file implements name lookups for the tables generated from the
+// Bluetooth numbers database, see the gen-uuids target in the Makefile, and
+// for vendor UUIDs registered at runtime, usually by code generated with
+// tools/gen-vendor-uuids.
+
+import "sync"
+
+// UUIDKind tells whether a UUID identifies a service, a characteristic or a
+// descriptor.
+type UUIDKind uint8
+
+const (
+	UUIDKindService UUIDKind = iota + 1
+	UUIDKindCharacteristic
+	UUIDKindDescriptor
+)
+
+// UUIDInfo describes a service, characteristic or descriptor UUID.
+type UUIDInfo struct {
+	UUID UUID
+	Name string
+	Kind UUIDKind
+
+	// Format is the format of the value of a characteristic or descriptor,
+	// for example "uint8", "sint16" or "utf8s" as in the characteristic
+	// presentation format. It is empty if unknown.
+	Format string
+}
+
+// uuidRegistry holds the UUIDs registered with RegisterUUIDs.
+var uuidRegistry struct {
+	lock  sync.RWMutex
+	infos map[UUID]UUIDInfo
+}
+
+// RegisterUUIDs adds vendor UUIDs to the name lookups. A registered UUID takes
+// precedence over a standard UUID with the same value.
+func RegisterUUIDs(infos ...UUIDInfo) {
+	uuidRegistry.lock.Lock()
+	defer uuidRegistry.lock.Unlock()
+	if uuidRegistry.infos == nil {
+		uuidRegistry.infos = make(map[UUID]UUIDInfo)
+	}
+	for _, info := range infos {
+		uuidRegistry.infos[info.UUID] = info
+	}
+}
+
+// LookupUUID returns the description of a registered or standard UUID.
+// Standard UUIDs have no Format.
+func LookupUUID(uuid UUID) (UUIDInfo, bool) {
+	uuidRegistry.lock.RLock()
+	info, ok := uuidRegistry.infos[uuid]
+	uuidRegistry.lock.RUnlock()
+	if ok {
+		return info, true
+	}
+	if name, ok := serviceUUIDNames[uuid]; ok {
+		return UUIDInfo{UUID: uuid, Name: name, Kind: UUIDKindService}, true
+	}
+	if name, ok := characteristicUUIDNames[uuid]; ok {
+		return UUIDInfo{UUID: uuid, Name: name, Kind: UUIDKindCharacteristic}, true
+	}
+	if name, ok := descriptorUUIDNames[uuid]; ok {
+		return UUIDInfo{UUID: uuid, Name: name, Kind: UUIDKindDescriptor}, true
+	}
+	return UUIDInfo{}, false
+}
+
+// lookupName returns the name of a registered or standard UUID of the given
+// kind.
+func lookupName(uuid UUID, kind UUIDKind) string {
+	info, ok := LookupUUID(uuid)
+	if !ok || info.Kind != kind {
+		return ""
+	}
+	return info.Name
+}
+
+// Appearance is the external appearance of a device, as advertised in the
+// Appearance data type and the GAP Appearance characteristic. The upper ten
+// bits are the category and the lower six bits the subcategory.
+type Appearance uint16
+
+// Category returns the appearance without the subcategory.
+func (a Appearance) Category() Appearance {
+	return a &^ 0x3f
+}
+
+// Subcategory returns the subcategory, which is 0 for a generic device of
+// the category.
+func (a Appearance) Subcategory() uint8 {
+	return uint8(a & 0x3f)
+}
+
+// String returns the name of the appearance. For an unknown subcategory it
+// returns the name of the category, and "" if the category is unknown too.
+// Only the curated subset of appearance values in data/gap_appearance.json
+// is known.
+func (a Appearance) String() string {
+	if name, ok := appearanceNames[a]; ok {
+		return name
+	}
+	return appearanceNames[a.Category()]
+}
+
+// ServiceName returns the name of a registered or standard service, or "" if
+// the UUID is not a known service.
+func ServiceName(uuid UUID) string {
+	return lookupName(uuid, UUIDKindService)
+}
+
+// CharacteristicName returns the name of a registered or standard
+// characteristic, or "" if the UUID is not a known characteristic.
+func CharacteristicName(uuid UUID) string {
+	return lookupName(uuid, UUIDKindCharacteristic)
+}
+
+// DescriptorName returns the name of a registered or standard descriptor, or
+// "" if the UUID is not a known descriptor. The standard descriptors are a
+// curated subset, see data/descriptor_uuids.json.
+func DescriptorName(uuid UUID) string {
+	return lookupName(uuid, UUIDKindDescriptor)
+}
+
+// CompanyName returns the name of the company with the given identifier, as
+// used in manufacturer data, or "" if it is not known. Only the curated subset
+// of companies in data/company_ids.json is known.
+func CompanyName(id uint16) string {
+	return companyNames[id]
+}
@@ -0,0 +1,57 @@
+package bluetooth
+
+import "testing"
+
+func TestNames(t *testing.T) {
+	if name := ServiceName(ServiceUUIDHeartRate); name != "Heart Rate" {
+		t.Errorf("ServiceName: unexpected %q", name)
+	}
+	if name := CharacteristicName(CharacteristicUUIDHeartRateMeasurement); name != "Heart Rate Measurement" {
+		t.Errorf("CharacteristicName: unexpected %q", name)
+	}
+	if name := DescriptorName(DescriptorUUIDClientCharacteristicConfiguration); name != "Client Characteristic Configuration" {
+		t.Errorf("DescriptorName: unexpected %q", name)
+	}
+	if name := ServiceName(New16BitUUID(0xffff)); name != "" {
+		t.Errorf("ServiceName: expected no name for an unknown UUID, got %q", name)
+	}
+	if name := CompanyName(0x004c); name != "Apple, Inc." {
+		t.Errorf("CompanyName: unexpected %q", name)
+	}
+
+	if name := AppearanceWatchSportsWatch.String(); name != "Sports Watch" {
+		t.Errorf("Appearance.String: unexpected %q", name)
+	}
+	if a := Appearance(0x00c5); a.Category() != AppearanceWatch || a.Subcategory() != 5 || a.String() != "Watch" {
+		t.Errorf("unexpected appearance %#04x: %#04x %d %q", uint16(a), uint16(a.Category()), a.Subcategory(), a.String())
+	}
+}
+
+func TestRegisterUUIDs(t *testing.T) {
+	// Run against an empty registry and restore it afterwards, so that the
+	// test can be repeated and does not leak into other tests.
+	uuidRegistry.lock.Lock()
+	saved := uuidRegistry.infos
+	uuidRegistry.infos = nil
+	uuidRegistry.lock.Unlock()
+	defer func() {
+		uuidRegistry.lock.Lock()
+		uuidRegistry.infos = saved
+		uuidRegistry.lock.Unlock()
+	}()
+
+	uuid, _ := ParseUUID("a0b10001-4c2d-4e8f-9a3b-1c2d3e4f5a6b")
+	if ServiceName(uuid) != "" {
+		t.Fatal("expected the vendor UUID to be unknown before registration")
+	}
+	RegisterUUIDs(UUIDInfo{UUID: uuid, Name: "Acme Sensor", Kind: UUIDKindService})
+	if name := ServiceName(uuid); name != "Acme Sensor" {
+		t.Errorf("ServiceName: unexpected %q", name)
+	}
+	if name := CharacteristicName(uuid); name != "" {
+		t.Errorf("CharacteristicName: expected no name for a service, got %q", name)
+	}
+	if name := ServiceName(ServiceUUIDHeartRate); name != "Heart Rate" {
+		t.Errorf("ServiceName: standard names must still be found, got %q", name)
+	}
+}
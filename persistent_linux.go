@@ -0,0 +1,546 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/muka/go-bluetooth/bluez"
+)
+
+var errPersistentClosed = errors.New("bluetooth: persistent device is closed")
+
+// PersistentState is the state of the connection of a PersistentDevice.
+type PersistentState uint8
+
+const (
+	// StateDisconnected means there is no connection and no connection attempt
+	// in progress.
+	StateDisconnected PersistentState = iota
+
+	// StateConnecting means a connection attempt is in progress.
+	StateConnecting
+
+	// StateConnected means the link is up, but services are not yet known.
+	StateConnected
+
+	// StateResolving means services are being discovered and notifications
+	// are being enabled.
+	StateResolving
+
+	// StateReady means the device is connected, its services are known and
+	// all subscriptions are active.
+	StateReady
+
+	// StateBackoff means the last attempt failed or the link dropped, and the
+	// next attempt is delayed.
+	StateBackoff
+
+	// StateClosed means Close was called. The PersistentDevice cannot be used
+	// anymore.
+	StateClosed
+)
+
+// String returns a human-readable name of the state.
+func (s PersistentState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateResolving:
+		return "resolving"
+	case StateReady:
+		return "ready"
+	case StateBackoff:
+		return "backoff"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// PersistentOptions configures a PersistentDevice.
+type PersistentOptions struct {
+	// Params are passed to Adapter.Connect on every connection attempt.
+	Params ConnectionParams
+
+	// Services are the services to discover after every connect. Passing nil
+	// discovers all services.
+	Services []UUID
+
+	// MinBackoff is the delay after the first failed attempt or link drop.
+	// It doubles on every following failure or drop up to MaxBackoff, and is
+	// reset once a link stayed up for MaxBackoff. The defaults are one second
+	// and one minute.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// StateChanged is called on every state transition. The error is the
+	// reason for entering StateBackoff or StateDisconnected, if any. It is
+	// called without internal locks held, so it may call the methods of the
+	// PersistentDevice except Close, but it must not block.
+	StateChanged func(state PersistentState, err error)
+}
+
+// PersistentDevice keeps a connection to a device alive. When the link drops
+// it reconnects with backoff, rediscovers services and re-enables every
+// notification registered with Subscribe, so a single handle can be used
+// across radio dropouts.
+//
+// BlueZ can only connect to devices it has seen recently, so discovery should
+// be active while the device is out of range.
+type PersistentDevice struct {
+	adapter *Adapter
+	address Address
+	options PersistentOptions
+	link    persistentLink
+
+	lock          sync.Mutex
+	state         PersistentState
+	device        *Device
+	services      []DeviceService
+	subscriptions []*persistentSubscription
+	ready         chan struct{}
+	generation    int           // incremented on every connection attempt
+	changes       []stateChange // state changes not reported yet
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+	closeErr error // set by run before done is closed
+}
+
+// stateChange is a state transition to report to StateChanged.
+type stateChange struct {
+	state PersistentState
+	err   error
+}
+
+// persistentSubscription is a notification subscription that is restored on
+// every reconnect.
+type persistentSubscription struct {
+	service        UUID
+	characteristic UUID
+	callback       func(buf []byte)
+
+	// Set while the subscription is active on the current connection.
+	notify *notifyHandle
+}
+
+// notifyHandle is an enabled notification.
+type notifyHandle struct {
+	char DeviceCharacteristic
+	ch   chan *bluez.PropertyChanged
+}
+
+// persistentLink does the BlueZ calls of a PersistentDevice. It is replaced
+// in tests.
+type persistentLink interface {
+	connect(ctx context.Context) (*Device, error)
+	discover(ctx context.Context, device *Device) ([]DeviceService, error)
+	enable(ctx context.Context, services []DeviceService, sub *persistentSubscription) (*notifyHandle, error)
+	disable(notify *notifyHandle) error
+	disconnected(device *Device) <-chan struct{}
+	disconnect(device *Device) error
+}
+
+// ConnectPersistent starts keeping a connection to the given device alive. It
+// returns immediately; use WaitReady to wait for the first connection.
+func (a *Adapter) ConnectPersistent(address Address, options PersistentOptions) *PersistentDevice {
+	p := newPersistentDevice(a, address, options, nil)
+	p.link = bluezLink{p}
+	go p.run()
+	return p
+}
+
+// newPersistentDevice returns a PersistentDevice that is not started yet.
+func newPersistentDevice(a *Adapter, address Address, options PersistentOptions, link persistentLink) *PersistentDevice {
+	if options.MinBackoff <= 0 {
+		options.MinBackoff = time.Second
+	}
+	if options.MaxBackoff < options.MinBackoff {
+		options.MaxBackoff = time.Minute
+		if options.MaxBackoff < options.MinBackoff {
+			options.MaxBackoff = options.MinBackoff
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &PersistentDevice{
+		adapter: a,
+		address: address,
+		options: options,
+		link:    link,
+		ready:   make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+}
+
+// Address returns the address of the device.
+func (p *PersistentDevice) Address() Address {
+	return p.address
+}
+
+// State returns the current connection state.
+func (p *PersistentDevice) State() PersistentState {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.state
+}
+
+// Device returns the current connection, or nil if the device is not ready.
+// The returned Device becomes stale after the next disconnect.
+func (p *PersistentDevice) Device() *Device {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.state != StateReady {
+		return nil
+	}
+	return p.device
+}
+
+// Services returns the services discovered on the current connection, or nil
+// if the device is not ready.
+func (p *PersistentDevice) Services() []DeviceService {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.state != StateReady {
+		return nil
+	}
+	return p.services
+}
+
+// WaitReady waits until the device is ready and returns the current
+// connection.
+func (p *PersistentDevice) WaitReady(ctx context.Context) (*Device, error) {
+	for {
+		p.lock.Lock()
+		state, device, ready := p.state, p.device, p.ready
+		p.lock.Unlock()
+		switch state {
+		case StateReady:
+			return device, nil
+		case StateClosed:
+			return nil, errPersistentClosed
+		}
+		select {
+		case <-ready:
+		case <-p.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Subscribe enables notifications of the given characteristic and keeps them
+// enabled across reconnects. If the device is ready, notifications are enabled
+// right away and an error is returned if that fails.
+func (p *PersistentDevice) Subscribe(service, characteristic UUID, callback func(buf []byte)) error {
+	sub := &persistentSubscription{
+		service:        service,
+		characteristic: characteristic,
+		callback:       callback,
+	}
+
+	p.lock.Lock()
+	if p.state == StateClosed {
+		p.lock.Unlock()
+		return errPersistentClosed
+	}
+	p.subscriptions = append(p.subscriptions, sub)
+	if p.state != StateReady {
+		// Enabled by the next connection.
+		p.lock.Unlock()
+		return nil
+	}
+	services, generation := p.services, p.generation
+	p.lock.Unlock()
+
+	notify, err := p.link.enable(p.ctx, services, sub)
+	if err != nil {
+		return err
+	}
+	p.lock.Lock()
+	if p.generation != generation || p.state != StateReady || !p.subscribed(sub) {
+		// The connection dropped or the subscription was removed meanwhile.
+		p.lock.Unlock()
+		p.link.disable(notify)
+		return nil
+	}
+	sub.notify = notify
+	p.lock.Unlock()
+	return nil
+}
+
+// subscribed returns whether the subscription is still registered. It must be
+// called with the lock held.
+func (p *PersistentDevice) subscribed(sub *persistentSubscription) bool {
+	for _, s := range p.subscriptions {
+		if s == sub {
+			return true
+		}
+	}
+	return false
+}
+
+// Unsubscribe disables notifications of the given characteristic and stops
+// restoring them.
+func (p *PersistentDevice) Unsubscribe(service, characteristic UUID) error {
+	var notifies []*notifyHandle
+	p.lock.Lock()
+	subscriptions := p.subscriptions[:0]
+	for _, sub := range p.subscriptions {
+		if sub.service == service && sub.characteristic == characteristic {
+			if sub.notify != nil {
+				notifies = append(notifies, sub.notify)
+				sub.notify = nil
+			}
+			continue
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+	p.subscriptions = subscriptions
+	p.lock.Unlock()
+
+	var err error
+	for _, notify := range notifies {
+		if e := p.link.disable(notify); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Close stops reconnecting and disconnects from the device. A connection
+// attempt or service discovery in progress is canceled. It waits until the
+// notifications are disabled and the device is disconnected, and returns the
+// first error of doing so.
+//
+// Close must not be called from the StateChanged callback, which runs on the
+// goroutine that Close waits for; cancel from another goroutine instead.
+func (p *PersistentDevice) Close() error {
+	p.cancel()
+	<-p.done
+	return p.closeErr
+}
+
+// setState changes the state. It must be called with the lock held. The
+// StateChanged callback is called by unlock once the lock is released, so the
+// callback may call the methods of the PersistentDevice.
+func (p *PersistentDevice) setState(state PersistentState, err error) {
+	if p.state == state && err == nil {
+		return
+	}
+	previous := p.state
+	p.state = state
+	if state == StateReady {
+		close(p.ready)
+	} else if previous == StateReady {
+		p.ready = make(chan struct{})
+	}
+	p.changes = append(p.changes, stateChange{state, err})
+}
+
+// unlock releases the lock and reports the state changes made while it was
+// held.
+func (p *PersistentDevice) unlock() {
+	changes := p.changes
+	p.changes = nil
+	p.lock.Unlock()
+	if p.options.StateChanged == nil {
+		return
+	}
+	for _, change := range changes {
+		p.options.StateChanged(change.state, change.err)
+	}
+}
+
+// run is the connection loop of a PersistentDevice.
+func (p *PersistentDevice) run() {
+	defer close(p.done)
+	ctx := p.ctx
+	backoff := p.options.MinBackoff
+	for {
+		device, err := p.connect(ctx)
+		switch {
+		case ctx.Err() != nil:
+			p.closeErr = p.teardown(device, StateClosed, nil)
+			return
+		case err != nil:
+			p.teardown(device, StateBackoff, err)
+		default:
+			// The device was ready. Wait until the link drops.
+			up := time.Now()
+			select {
+			case <-p.link.disconnected(device):
+			case <-ctx.Done():
+				p.closeErr = p.teardown(device, StateClosed, nil)
+				return
+			}
+			if time.Since(up) >= p.options.MaxBackoff {
+				// The link was stable, so this is not a device that
+				// drops right after connecting.
+				backoff = p.options.MinBackoff
+			}
+			p.teardown(nil, StateDisconnected, nil)
+			p.lock.Lock()
+			p.setState(StateBackoff, nil)
+			p.unlock()
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			p.lock.Lock()
+			p.setState(StateClosed, nil)
+			p.unlock()
+			return
+		}
+		backoff *= 2
+		if backoff > p.options.MaxBackoff {
+			backoff = p.options.MaxBackoff
+		}
+	}
+}
+
+// connect does a single connection attempt. It returns the device, which may
+// be nil, and nil once the device is ready.
+func (p *PersistentDevice) connect(ctx context.Context) (*Device, error) {
+	p.lock.Lock()
+	p.device = nil
+	p.generation++
+	p.setState(StateConnecting, nil)
+	p.unlock()
+
+	device, err := p.link.connect(ctx)
+	if err != nil {
+		return device, err
+	}
+
+	p.lock.Lock()
+	p.device = device
+	p.setState(StateConnected, nil)
+	p.setState(StateResolving, nil)
+	p.unlock()
+
+	services, err := p.link.discover(ctx, device)
+	if err != nil {
+		return device, err
+	}
+
+	p.lock.Lock()
+	p.services = services
+	subscriptions := append([]*persistentSubscription(nil), p.subscriptions...)
+	p.lock.Unlock()
+
+	for _, sub := range subscriptions {
+		notify, err := p.link.enable(ctx, services, sub)
+		if err != nil {
+			return device, err
+		}
+		p.lock.Lock()
+		if !p.subscribed(sub) {
+			// Removed by Unsubscribe meanwhile.
+			p.lock.Unlock()
+			p.link.disable(notify)
+			continue
+		}
+		sub.notify = notify
+		p.lock.Unlock()
+	}
+	if ctx.Err() != nil {
+		return device, ctx.Err()
+	}
+
+	p.lock.Lock()
+	p.setState(StateReady, nil)
+	p.unlock()
+	return device, nil
+}
+
+// teardown drops the notifications of the current connection, disconnects
+// the device if it is given and changes the state. It returns the first error
+// of disabling the notifications and disconnecting.
+func (p *PersistentDevice) teardown(device *Device, state PersistentState, err error) error {
+	var notifies []*notifyHandle
+	p.lock.Lock()
+	for _, sub := range p.subscriptions {
+		if sub.notify != nil {
+			notifies = append(notifies, sub.notify)
+			sub.notify = nil
+		}
+	}
+	p.services = nil
+	p.lock.Unlock()
+
+	var firstErr error
+	for _, notify := range notifies {
+		if e := p.link.disable(notify); e != nil && firstErr == nil {
+			firstErr = e
+		}
+	}
+	if device != nil {
+		if e := p.link.disconnect(device); e != nil && firstErr == nil {
+			firstErr = e
+		}
+	}
+
+	p.lock.Lock()
+	p.setState(state, err)
+	p.unlock()
+	return firstErr
+}
+
+// bluezLink is the persistentLink that talks to BlueZ.
+type bluezLink struct {
+	p *PersistentDevice
+}
+
+func (l bluezLink) connect(ctx context.Context) (*Device, error) {
+	return l.p.adapter.ConnectContext(ctx, l.p.address, l.p.options.Params)
+}
+
+func (l bluezLink) discover(ctx context.Context, device *Device) ([]DeviceService, error) {
+	return device.DiscoverServicesContext(ctx, l.p.options.Services)
+}
+
+// enable looks up the characteristic of the subscription in the given
+// services and enables notifications on it.
+func (l bluezLink) enable(ctx context.Context, services []DeviceService, sub *persistentSubscription) (*notifyHandle, error) {
+	for i := range services {
+		if services[i].UUID() != sub.service {
+			continue
+		}
+		chars, err := services[i].DiscoverCharacteristics([]UUID{sub.characteristic})
+		if err != nil {
+			return nil, err
+		}
+		ch, err := chars[0].EnableNotificationsContext(ctx, sub.callback)
+		if err != nil {
+			return nil, err
+		}
+		return &notifyHandle{chars[0], ch}, nil
+	}
+	return nil, errors.New("bluetooth: could not find service " + sub.service.String())
+}
+
+func (l bluezLink) disable(notify *notifyHandle) error {
+	return notify.char.DisableNotifications(notify.ch)
+}
+
+func (l bluezLink) disconnected(device *Device) <-chan struct{} {
+	return device.Disconnected()
+}
+
+func (l bluezLink) disconnect(device *Device) error {
+	return device.Disconnect()
+}
@@ -0,0 +1,262 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLink is a persistentLink that connects to fake devices.
+type fakeLink struct {
+	lock          sync.Mutex
+	connectErrs   []error // returned by the next connects, nil connects
+	block         bool    // connect blocks until the context is done
+	disconnectErr error   // returned by disconnect
+	links         map[*Device]chan struct{}
+	enabled       int
+	disabled      int
+	disconnects   int
+	connectCalls  int
+}
+
+func newFakeLink() *fakeLink {
+	return &fakeLink{links: make(map[*Device]chan struct{})}
+}
+
+func (l *fakeLink) connect(ctx context.Context) (*Device, error) {
+	l.lock.Lock()
+	l.connectCalls++
+	block := l.block
+	var err error
+	if len(l.connectErrs) != 0 {
+		err = l.connectErrs[0]
+		l.connectErrs = l.connectErrs[1:]
+	}
+	l.lock.Unlock()
+	if block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	d := &Device{}
+	l.lock.Lock()
+	l.links[d] = make(chan struct{})
+	l.lock.Unlock()
+	return d, nil
+}
+
+func (l *fakeLink) discover(ctx context.Context, device *Device) ([]DeviceService, error) {
+	return nil, nil
+}
+
+func (l *fakeLink) enable(ctx context.Context, services []DeviceService, sub *persistentSubscription) (*notifyHandle, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.enabled++
+	return &notifyHandle{}, nil
+}
+
+func (l *fakeLink) disable(notify *notifyHandle) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.disabled++
+	return nil
+}
+
+func (l *fakeLink) disconnected(device *Device) <-chan struct{} {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.links[device]
+}
+
+func (l *fakeLink) disconnect(device *Device) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.disconnects++
+	return l.disconnectErr
+}
+
+// drop drops the link to the device.
+func (l *fakeLink) drop(device *Device) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	close(l.links[device])
+}
+
+// startPersistent starts a PersistentDevice on the link and returns a channel
+// with its state changes.
+func startPersistent(link persistentLink, options PersistentOptions) (*PersistentDevice, <-chan PersistentState) {
+	states := make(chan PersistentState, 64)
+	var p *PersistentDevice
+	options.StateChanged = func(state PersistentState, err error) {
+		// Calling back into the device must not deadlock.
+		p.State()
+		p.Device()
+		p.Services()
+		states <- state
+	}
+	p = newPersistentDevice(nil, Address{}, options, link)
+	go p.run()
+	return p, states
+}
+
+// expectStates reads the expected states from the channel.
+func expectStates(t *testing.T, states <-chan PersistentState, expected ...PersistentState) {
+	t.Helper()
+	for _, state := range expected {
+		select {
+		case got := <-states:
+			if got != state {
+				t.Fatalf("expected state %s, got %s", state, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for state %s", state)
+		}
+	}
+}
+
+func TestPersistentReconnect(t *testing.T) {
+	link := newFakeLink()
+	p, states := startPersistent(link, PersistentOptions{MinBackoff: time.Millisecond})
+	if err := p.Subscribe(UUID{}, UUID{}, func([]byte) {}); err != nil {
+		t.Fatal(err)
+	}
+	device, err := p.WaitReady(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStates(t, states, StateConnecting, StateConnected, StateResolving, StateReady)
+
+	// The link drops, the device reconnects and restores the subscription.
+	link.drop(device)
+	expectStates(t, states, StateDisconnected, StateBackoff, StateConnecting, StateConnected, StateResolving, StateReady)
+	if p.Device() == device {
+		t.Error("device not replaced after reconnect")
+	}
+	link.lock.Lock()
+	enabled, disabled := link.enabled, link.disabled
+	link.lock.Unlock()
+	if enabled != 2 || disabled != 1 {
+		t.Errorf("expected 2 enables and 1 disable, got %d and %d", enabled, disabled)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	expectStates(t, states, StateClosed)
+	if _, err := p.WaitReady(context.Background()); err != errPersistentClosed {
+		t.Errorf("WaitReady after Close: %v", err)
+	}
+	link.lock.Lock()
+	defer link.lock.Unlock()
+	if link.disabled != 2 || link.disconnects != 1 {
+		t.Errorf("expected 2 disables and 1 disconnect after Close, got %d and %d", link.disabled, link.disconnects)
+	}
+}
+
+func TestPersistentBackoff(t *testing.T) {
+	link := newFakeLink()
+	failure := errors.New("connect failed")
+	link.connectErrs = []error{failure, failure}
+	var errs []error
+	var lock sync.Mutex
+	options := PersistentOptions{
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 2 * time.Millisecond,
+		StateChanged: func(state PersistentState, err error) {
+			lock.Lock()
+			if state == StateBackoff {
+				errs = append(errs, err)
+			}
+			lock.Unlock()
+		},
+	}
+	p := newPersistentDevice(nil, Address{}, options, link)
+	go p.run()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := p.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	lock.Lock()
+	defer lock.Unlock()
+	link.lock.Lock()
+	defer link.lock.Unlock()
+	if link.connectCalls != 3 {
+		t.Errorf("expected 3 connection attempts, got %d", link.connectCalls)
+	}
+	if len(errs) != 2 {
+		t.Errorf("expected 2 backoffs, got %d", len(errs))
+	}
+	for _, err := range errs {
+		if err != failure {
+			t.Errorf("unexpected backoff error: %v", err)
+		}
+	}
+}
+
+func TestPersistentCloseWhileConnecting(t *testing.T) {
+	link := newFakeLink()
+	link.block = true
+	p, states := startPersistent(link, PersistentOptions{})
+	expectStates(t, states, StateConnecting)
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not interrupt the connection attempt")
+	}
+	expectStates(t, states, StateClosed)
+}
+
+func TestPersistentBackoffAfterDrop(t *testing.T) {
+	link := newFakeLink()
+	const minBackoff = 20 * time.Millisecond
+	p, states := startPersistent(link, PersistentOptions{MinBackoff: minBackoff, MaxBackoff: time.Minute})
+	defer p.Close()
+
+	// A device that drops right after connecting is reconnected with a
+	// growing delay: 20, 40 and 80 ms.
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		device, err := p.WaitReady(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		link.drop(device)
+		expectStates(t, states, StateConnecting, StateConnected, StateResolving, StateReady, StateDisconnected, StateBackoff)
+	}
+	if _, err := p.WaitReady(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 7*minBackoff {
+		t.Errorf("reconnected too fast after drops: %v", elapsed)
+	}
+}
+
+func TestPersistentCloseError(t *testing.T) {
+	link := newFakeLink()
+	failure := errors.New("disconnect failed")
+	link.disconnectErr = failure
+	p, _ := startPersistent(link, PersistentOptions{})
+	if _, err := p.WaitReady(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != failure {
+		t.Errorf("expected the disconnect error from Close, got %v", err)
+	}
+}
@@ -0,0 +1,450 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	errPoolEmpty = errors.New("bluetooth: no adapter in the pool is available")
+	errPoolFull  = errors.New("bluetooth: all adapters in the pool are at their connection limit")
+)
+
+// PoolStrategy selects the adapter that an AdapterPool uses for a new
+// connection.
+type PoolStrategy uint8
+
+const (
+	// PoolFewestLinks connects through the adapter with the fewest active
+	// links. Ties are broken by the best recent RSSI.
+	PoolFewestLinks PoolStrategy = iota
+
+	// PoolBestRSSI connects through the adapter that most recently received
+	// the device with the strongest signal. Adapters that did not see the
+	// device recently are used last, with the fewest links first.
+	PoolBestRSSI
+)
+
+// AdapterPoolOptions configures an AdapterPool.
+type AdapterPoolOptions struct {
+	// Strategy selects the adapter for each connection.
+	Strategy PoolStrategy
+
+	// MaxLinks is the number of connections each adapter can hold. Most
+	// controllers support somewhere between 5 and 20. Zero means no limit.
+	MaxLinks int
+
+	// Limits overrides MaxLinks for individual adapters, by adapter ID such
+	// as "hci1".
+	Limits map[string]int
+
+	// RSSIWindow is how long a received RSSI is used to select an adapter.
+	// The default is ten seconds.
+	RSSIWindow time.Duration
+}
+
+// poolMember is an adapter in an AdapterPool.
+type poolMember struct {
+	adapter *Adapter
+	links   int
+	down    bool
+	gen     int // incremented when the links are dropped
+}
+
+// poolSighting is the last RSSI of a device on one adapter.
+type poolSighting struct {
+	rssi int16
+	at   time.Time
+}
+
+// AdapterPool spreads connections over several adapters. It scans on all of
+// them, connects each device through the adapter selected by the strategy
+// and tries the next adapter when a connection attempt fails. It is safe for
+// concurrent use.
+type AdapterPool struct {
+	options AdapterPoolOptions
+
+	lock      sync.Mutex
+	members   []*poolMember
+	sightings map[MAC]map[*Adapter]poolSighting
+	pruned    time.Time // last time expired sightings were removed
+}
+
+// NewAdapterPool returns a pool with the given adapters, which must be
+// enabled.
+func NewAdapterPool(options AdapterPoolOptions, adapters ...*Adapter) *AdapterPool {
+	if options.RSSIWindow <= 0 {
+		options.RSSIWindow = 10 * time.Second
+	}
+	p := &AdapterPool{
+		options:   options,
+		sightings: make(map[MAC]map[*Adapter]poolSighting),
+	}
+	for _, a := range adapters {
+		p.Add(a)
+	}
+	return p
+}
+
+// Add adds an enabled adapter to the pool. Adding an adapter twice has no
+// effect.
+func (p *AdapterPool) Add(a *Adapter) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.member(a) == nil {
+		p.members = append(p.members, &poolMember{adapter: a})
+	}
+}
+
+// Remove removes an adapter from the pool. Existing connections through the
+// adapter are not affected.
+func (p *AdapterPool) Remove(a *Adapter) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for i, m := range p.members {
+		if m.adapter == a {
+			p.members = append(p.members[:i], p.members[i+1:]...)
+			break
+		}
+	}
+	for mac, sightings := range p.sightings {
+		delete(sightings, a)
+		if len(sightings) == 0 {
+			delete(p.sightings, mac)
+		}
+	}
+}
+
+// Adapters returns the adapters in the pool.
+func (p *AdapterPool) Adapters() []*Adapter {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	adapters := make([]*Adapter, len(p.members))
+	for i, m := range p.members {
+		adapters[i] = m.adapter
+	}
+	return adapters
+}
+
+// Links returns the number of connections the pool holds through the given
+// adapter.
+func (p *AdapterPool) Links(a *Adapter) int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if m := p.member(a); m != nil {
+		return m.links
+	}
+	return 0
+}
+
+// member returns the pool member of an adapter, or nil. The lock must be
+// held.
+func (p *AdapterPool) member(a *Adapter) *poolMember {
+	for _, m := range p.members {
+		if m.adapter == a {
+			return m
+		}
+	}
+	return nil
+}
+
+// limit returns the connection limit of an adapter, or 0 for no limit.
+func (p *AdapterPool) limit(a *Adapter) int {
+	if limit, ok := p.options.Limits[a.id]; ok {
+		return limit
+	}
+	return p.options.MaxLinks
+}
+
+// Scan scans on all adapters in the pool until StopScan is called, and
+// records the RSSI of each scan result to select adapters in Connect. The
+// callback may be called from several goroutines at once. Scan returns the
+// first error of any adapter once all scans have stopped.
+func (p *AdapterPool) Scan(filter map[string]interface{}, callback func(*Adapter, ScanResult)) error {
+	adapters := p.Adapters()
+	if len(adapters) == 0 {
+		return errPoolEmpty
+	}
+	errs := make(chan error, len(adapters))
+	for _, a := range adapters {
+		go func(a *Adapter) {
+			errs <- a.Scan(filter, func(a *Adapter, result ScanResult) {
+				p.HandleScanResult(a, result)
+				if callback != nil {
+					callback(a, result)
+				}
+			})
+		}(a)
+	}
+	var firstErr error
+	for range adapters {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// StopScan stops the scans of all adapters in the pool.
+func (p *AdapterPool) StopScan() error {
+	var firstErr error
+	for _, a := range p.Adapters() {
+		if err := a.StopScan(); err != nil && err != errNotScanning && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// HandleScanResult records the RSSI of a scan result received by the given
+// adapter. It is called by Scan, and can be called directly when scanning
+// in another way.
+func (p *AdapterPool) HandleScanResult(a *Adapter, result ScanResult) {
+	address, ok := makeAddress(result.Address)
+	if !ok || result.RSSI == 0 {
+		return
+	}
+	now := time.Now()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.expireSightings(now)
+	sightings := p.sightings[address.MAC]
+	if sightings == nil {
+		sightings = make(map[*Adapter]poolSighting)
+		p.sightings[address.MAC] = sightings
+	}
+	sightings[a] = poolSighting{result.RSSI, now}
+}
+
+// expireSightings removes the sightings that are older than the RSSI window,
+// so that devices that were seen once do not stay in the pool forever. It
+// does so at most once per window. The lock must be held.
+func (p *AdapterPool) expireSightings(now time.Time) {
+	if now.Sub(p.pruned) < p.options.RSSIWindow {
+		return
+	}
+	p.pruned = now
+	for mac, sightings := range p.sightings {
+		for a, s := range sightings {
+			if now.Sub(s.at) > p.options.RSSIWindow {
+				delete(sightings, a)
+			}
+		}
+		if len(sightings) == 0 {
+			delete(p.sightings, mac)
+		}
+	}
+}
+
+// poolCandidate is an adapter that can be used for a connection.
+type poolCandidate struct {
+	adapter *Adapter
+	links   int
+	rssi    int16 // 0 if not seen within the RSSI window
+}
+
+// candidates returns the adapters that can take another connection to the
+// device, best first. The lock must be held.
+func (p *AdapterPool) candidates(mac MAC, now time.Time) (candidates []poolCandidate, full bool) {
+	for _, m := range p.members {
+		if m.down {
+			continue
+		}
+		if limit := p.limit(m.adapter); limit > 0 && m.links >= limit {
+			full = true
+			continue
+		}
+		c := poolCandidate{adapter: m.adapter, links: m.links}
+		if s, ok := p.sightings[mac][m.adapter]; ok && now.Sub(s.at) <= p.options.RSSIWindow {
+			c.rssi = s.rssi
+		}
+		candidates = append(candidates, c)
+	}
+	sortPoolCandidates(candidates, p.options.Strategy)
+	return candidates, full
+}
+
+// sortPoolCandidates sorts candidates according to the strategy, best first.
+func sortPoolCandidates(candidates []poolCandidate, strategy PoolStrategy) {
+	// An RSSI of 0 means not seen, which is worse than any real RSSI.
+	betterRSSI := func(a, b int16) bool {
+		if a == 0 || b == 0 {
+			return a != 0 && b == 0
+		}
+		return a > b
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if strategy == PoolBestRSSI {
+			if a.rssi != b.rssi {
+				return betterRSSI(a.rssi, b.rssi)
+			}
+			return a.links < b.links
+		}
+		if a.links != b.links {
+			return a.links < b.links
+		}
+		return betterRSSI(a.rssi, b.rssi)
+	})
+}
+
+// Connect connects to the device through the adapter selected by the
+// strategy. Adapters that are powered off, for example during a reset, or
+// that have been unplugged are skipped, and when a connection attempt fails
+// the next adapter is tried. The error of the last attempt is returned if
+// all of them fail.
+func (p *AdapterPool) Connect(address Addresser, params ConnectionParams) (*Device, error) {
+	adr, ok := makeAddress(address)
+	if !ok {
+		return nil, errInvalidAddressType
+	}
+	p.lock.Lock()
+	candidates, full := p.candidates(adr.MAC, time.Now())
+	p.lock.Unlock()
+
+	err := errPoolEmpty
+	if len(candidates) == 0 && full {
+		err = errPoolFull
+	}
+	for _, c := range candidates {
+		if powered, perr := c.adapter.Powered(); perr != nil || !powered {
+			continue
+		}
+		gen, ok := p.acquire(c.adapter)
+		if !ok {
+			// The adapter filled up in the meantime.
+			err = errPoolFull
+			continue
+		}
+		var device *Device
+		device, err = c.adapter.Connect(adr, params)
+		if err != nil {
+			p.release(c.adapter, gen)
+			continue
+		}
+		go func(a *Adapter) {
+			// Disconnected is also closed when the device is removed
+			// and when bluetoothd stops.
+			<-device.Disconnected()
+			p.release(a, gen)
+		}(c.adapter)
+		return device, nil
+	}
+	return nil, err
+}
+
+// acquire takes a connection slot on the adapter, if the limit allows it.
+// It returns the generation of the slot to pass to release.
+func (p *AdapterPool) acquire(a *Adapter) (int, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	m := p.member(a)
+	if m == nil || m.down {
+		return 0, false
+	}
+	if limit := p.limit(a); limit > 0 && m.links >= limit {
+		return 0, false
+	}
+	m.links++
+	return m.gen, true
+}
+
+// release gives back a connection slot taken with acquire. Slots that were
+// dropped when the adapter was unplugged are ignored.
+func (p *AdapterPool) release(a *Adapter, gen int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if m := p.member(a); m != nil && m.gen == gen && m.links > 0 {
+		m.links--
+	}
+}
+
+// Watch follows adapters being unplugged and plugged in again, and
+// bluetoothd stopping, until the context is canceled. An unplugged adapter is
+// not used and its links are dropped; when it comes back it is enabled again
+// and used as before. When bluetoothd stops, the links of all adapters are
+// dropped.
+func (p *AdapterPool) Watch(ctx context.Context) error {
+	events, err := WatchAdapters(ctx)
+	if err != nil {
+		return err
+	}
+	stackEvents, err := WatchStack(ctx)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for event := range events {
+			p.handleHotplug(event)
+		}
+	}()
+	go func() {
+		for event := range stackEvents {
+			if event.Type == StackStopped {
+				p.dropLinks()
+			}
+		}
+	}()
+	return nil
+}
+
+// handleHotplug marks the members with the adapter ID of the event as down
+// or up.
+func (p *AdapterPool) handleHotplug(event AdapterHotplugEvent) {
+	p.lock.Lock()
+	var members []*poolMember
+	for _, m := range p.members {
+		if m.adapter.id == event.Adapter.ID {
+			members = append(members, m)
+		}
+	}
+	p.lock.Unlock()
+
+	for _, m := range members {
+		// An adapter closed with Close stays closed until the application
+		// calls Enable.
+		down := event.Type == AdapterRemoved || m.adapter.isClosed()
+		if !down && m.adapter.enable() != nil {
+			down = true
+		}
+		p.handleHotplugState(m.adapter, down)
+	}
+}
+
+// handleHotplugState marks an adapter as unplugged or available again. The
+// links of an unplugged adapter are dropped.
+func (p *AdapterPool) handleHotplugState(a *Adapter, down bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	m := p.member(a)
+	if m == nil {
+		return
+	}
+	if down && !m.down {
+		m.dropLinks()
+	}
+	m.down = down
+}
+
+// dropLinks drops the links of all adapters, because bluetoothd stopped and
+// took the connections with it.
+func (p *AdapterPool) dropLinks() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, m := range p.members {
+		m.dropLinks()
+	}
+}
+
+// dropLinks forgets the links of the adapter. Slots acquired before are
+// ignored when they are released.
+func (m *poolMember) dropLinks() {
+	m.links = 0
+	m.gen++
+}
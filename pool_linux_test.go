@@ -0,0 +1,117 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdapterPoolCandidates(t *testing.T) {
+	hci0, hci1, hci2 := NewAdapter("hci0"), NewAdapter("hci1"), NewAdapter("hci2")
+	mac, _ := ParseMAC("11:22:33:44:55:66")
+	address := Address{MACAddress{MAC: mac}}
+	now := time.Now()
+
+	p := NewAdapterPool(AdapterPoolOptions{MaxLinks: 2, Limits: map[string]int{"hci2": 3}}, hci0, hci1, hci2)
+	p.pruned = time.Time{}
+	p.HandleScanResult(hci0, ScanResult{Address: address, RSSI: -80})
+	p.HandleScanResult(hci1, ScanResult{Address: address, RSSI: -50})
+	p.member(hci0).links = 0
+	p.member(hci1).links = 1
+	p.member(hci2).links = 0
+
+	order := func(candidates []poolCandidate) []string {
+		var ids []string
+		for _, c := range candidates {
+			ids = append(ids, c.adapter.id)
+		}
+		return ids
+	}
+	check := func(name string, want ...string) {
+		t.Helper()
+		candidates, _ := p.candidates(mac, now)
+		got := order(candidates)
+		if len(got) != len(want) {
+			t.Errorf("%s: expected %v but got %v", name, want, got)
+			return
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: expected %v but got %v", name, want, got)
+				return
+			}
+		}
+	}
+
+	check("fewest links", "hci0", "hci2", "hci1")
+	p.options.Strategy = PoolBestRSSI
+	check("best RSSI", "hci1", "hci0", "hci2")
+
+	// An old RSSI no longer counts.
+	p.sightings[mac][hci1] = poolSighting{-50, now.Add(-time.Minute)}
+	check("expired RSSI", "hci0", "hci2", "hci1")
+
+	// Full and unplugged adapters are skipped.
+	p.member(hci0).links = 2
+	p.member(hci2).down = true
+	check("limits", "hci1")
+	p.member(hci1).links = 2
+	if candidates, full := p.candidates(mac, now); len(candidates) != 0 || !full {
+		t.Errorf("expected a full pool, got %v", order(candidates))
+	}
+
+	// Slots from before an unplug are not released twice.
+	if _, ok := p.acquire(hci2); ok {
+		t.Error("acquired a slot on an unplugged adapter")
+	}
+	p.member(hci2).down = false
+	gen, ok := p.acquire(hci2)
+	if !ok || p.Links(hci2) != 1 {
+		t.Fatalf("could not acquire a slot: %v, %d", ok, p.Links(hci2))
+	}
+	p.handleHotplugState(hci2, true)
+	p.release(hci2, gen)
+	if p.Links(hci2) != 0 {
+		t.Errorf("expected no links after the unplug, got %d", p.Links(hci2))
+	}
+
+	// Nor after bluetoothd stopped.
+	gen, _ = p.acquire(hci2)
+	p.dropLinks()
+	p.release(hci2, gen)
+	if p.Links(hci2) != 0 {
+		t.Errorf("expected no links after bluetoothd stopped, got %d", p.Links(hci2))
+	}
+}
+
+func TestAdapterPoolExpireSightings(t *testing.T) {
+	hci0 := NewAdapter("hci0")
+	p := NewAdapterPool(AdapterPoolOptions{RSSIWindow: time.Second}, hci0)
+	old, _ := ParseMAC("11:22:33:44:55:66")
+	recent, _ := ParseMAC("22:33:44:55:66:77")
+	now := time.Now()
+	p.sightings[old] = map[*Adapter]poolSighting{hci0: {-60, now.Add(-2 * time.Second)}}
+	p.sightings[recent] = map[*Adapter]poolSighting{hci0: {-60, now}}
+
+	p.expireSightings(now)
+	if _, ok := p.sightings[old]; ok {
+		t.Error("expected the old sighting to be removed")
+	}
+	if _, ok := p.sightings[recent]; !ok {
+		t.Error("expected the recent sighting to be kept")
+	}
+
+	// Sightings are only checked once per window.
+	p.sightings[old] = map[*Adapter]poolSighting{hci0: {-60, now.Add(-2 * time.Second)}}
+	p.expireSightings(now.Add(time.Second / 2))
+	if _, ok := p.sightings[old]; !ok {
+		t.Error("expected no expiry within the window")
+	}
+	p.pruned = time.Time{}
+	p.HandleScanResult(hci0, ScanResult{Address: Address{MACAddress{MAC: recent}}, RSSI: -40})
+	if _, ok := p.sightings[old]; ok || len(p.sightings) != 1 {
+		t.Errorf("expected HandleScanResult to expire old sightings, got %d", len(p.sightings))
+	}
+}
@@ -0,0 +1,385 @@
+// Package proximity estimates the distance to Bluetooth devices from their
+// received signal strength and classifies them into proximity zones.
+//
+// Distances are estimated with the log-distance path-loss model:
+//
+//	RSSI = MeasuredPower - 10 * Exponent * log10(distance)
+//
+// where MeasuredPower is the RSSI at one meter. RSSI is noisy and depends a
+// lot on the environment, so distances are rough estimates; zones are usually
+// more useful than the distance itself.
+package proximity
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/GKoSon/gobluetooth"
+)
+
+var errNoSamples = errors.New("proximity: calibration needs samples at a distance other than 1 m")
+
+// TxPowerLoss is the usual difference in dB between the advertised transmit
+// power, which is the power at 0 m, and the RSSI at 1 m.
+const TxPowerLoss = 41
+
+// Model is a log-distance path-loss model.
+type Model struct {
+	// MeasuredPower is the RSSI in dBm at a distance of one meter, as in the
+	// measured power of an iBeacon.
+	MeasuredPower float64
+
+	// Exponent is the path-loss exponent. It is 2 in free space, and usually
+	// between 2 and 4 indoors.
+	Exponent float64
+}
+
+// DefaultModel is used for devices without calibration that do not advertise
+// their transmit power.
+var DefaultModel = Model{MeasuredPower: -59, Exponent: 2}
+
+// ModelFromTxPower returns a model for a device that advertises the given
+// transmit power, using the default exponent.
+func ModelFromTxPower(txPower int16) Model {
+	return Model{
+		MeasuredPower: float64(txPower) - TxPowerLoss,
+		Exponent:      DefaultModel.Exponent,
+	}
+}
+
+// Distance returns the estimated distance in meters for the given RSSI.
+func (m Model) Distance(rssi float64) float64 {
+	return math.Pow(10, (m.MeasuredPower-rssi)/(10*m.Exponent))
+}
+
+// RSSI returns the expected RSSI at the given distance in meters.
+func (m Model) RSSI(distance float64) float64 {
+	return m.MeasuredPower - 10*m.Exponent*math.Log10(distance)
+}
+
+// Sample is an RSSI measured at a known distance, used for calibration.
+type Sample struct {
+	Distance float64
+	RSSI     float64
+}
+
+// Calibrate fits a model to samples measured at known distances with least
+// squares. If all samples are taken at the same distance, only the measured
+// power is fitted and the exponent of DefaultModel is used.
+func Calibrate(samples []Sample) (Model, error) {
+	var n, sumX, sumY, sumXX, sumXY float64
+	for _, s := range samples {
+		if s.Distance <= 0 {
+			continue
+		}
+		x := -10 * math.Log10(s.Distance)
+		n++
+		sumX += x
+		sumY += s.RSSI
+		sumXX += x * x
+		sumXY += x * s.RSSI
+	}
+	if n == 0 {
+		return Model{}, errNoSamples
+	}
+	denominator := n*sumXX - sumX*sumX
+	if math.Abs(denominator) < 1e-9 {
+		// All samples at the same distance: x is constant.
+		exponent := DefaultModel.Exponent
+		return Model{
+			MeasuredPower: sumY/n - exponent*sumX/n,
+			Exponent:      exponent,
+		}, nil
+	}
+	exponent := (n*sumXY - sumX*sumY) / denominator
+	return Model{
+		MeasuredPower: (sumY - exponent*sumX) / n,
+		Exponent:      exponent,
+	}, nil
+}
+
+// Zone is a coarse proximity class, like the proximity of iBeacons.
+type Zone uint8
+
+const (
+	// ZoneUnknown means the device has not been seen recently.
+	ZoneUnknown Zone = iota
+
+	// ZoneImmediate means the device is very close, within centimeters.
+	ZoneImmediate
+
+	// ZoneNear means the device is within a few meters.
+	ZoneNear
+
+	// ZoneFar means the device is further away.
+	ZoneFar
+)
+
+// String returns a human-readable version of the zone.
+func (z Zone) String() string {
+	switch z {
+	case ZoneImmediate:
+		return "immediate"
+	case ZoneNear:
+		return "near"
+	case ZoneFar:
+		return "far"
+	}
+	return "unknown"
+}
+
+// Zones defines the boundaries between zones.
+type Zones struct {
+	// Immediate and Near are the upper bounds in meters of ZoneImmediate and
+	// ZoneNear. Anything further is ZoneFar.
+	Immediate float64
+	Near      float64
+
+	// Hysteresis is the fraction by which a distance must cross a boundary
+	// before the zone changes, so that a device near a boundary does not
+	// flip between zones. For example 0.2 moves a device from near to far
+	// at 1.2 times the near bound, and back at 0.8 times the near bound.
+	Hysteresis float64
+}
+
+// DefaultZones are the zones used when none are configured.
+var DefaultZones = Zones{Immediate: 0.5, Near: 3, Hysteresis: 0.2}
+
+// Classify returns the zone for the distance, given the current zone of the
+// device.
+func (z Zones) Classify(distance float64, current Zone) Zone {
+	if math.IsNaN(distance) || math.IsInf(distance, 0) {
+		return ZoneUnknown
+	}
+	bounds := [2]float64{z.Immediate, z.Near}
+	zone := ZoneImmediate
+	for i, bound := range bounds {
+		// The zone that would be left by crossing this bound.
+		inner := Zone(i) + ZoneImmediate
+		switch {
+		case current == ZoneUnknown:
+		case current <= inner:
+			// Moving outwards needs a larger distance.
+			bound *= 1 + z.Hysteresis
+		default:
+			// Moving inwards needs a smaller distance.
+			bound *= 1 - z.Hysteresis
+		}
+		if distance > bound {
+			zone = inner + 1
+		}
+	}
+	return zone
+}
+
+// Estimate is the proximity of a device.
+type Estimate struct {
+	// Address of the device.
+	Address string
+
+	// RSSI is the smoothed RSSI in dBm.
+	RSSI float64
+
+	// Distance is the estimated distance in meters.
+	Distance float64
+
+	// Zone is the proximity zone, with hysteresis applied.
+	Zone Zone
+
+	// Time is when the device was last seen.
+	Time time.Time
+}
+
+// Options configures an Estimator. The zero value is usable.
+type Options struct {
+	// Model is the path-loss model for devices that are not calibrated and
+	// do not advertise their transmit power. It defaults to DefaultModel.
+	Model Model
+
+	// Zones are the zone boundaries. They default to DefaultZones.
+	Zones Zones
+
+	// Alpha is the weight of a new RSSI sample in the exponential moving
+	// average, between 0 and 1. It defaults to 0.25.
+	Alpha float64
+
+	// Timeout is after how long without samples a device moves to
+	// ZoneUnknown in Expire. It defaults to 10 seconds.
+	Timeout time.Duration
+
+	// TTL is after how long without samples Expire removes a device, so that
+	// devices with rotating private addresses do not pile up. It defaults to
+	// one minute, and is never shorter than Timeout.
+	TTL time.Duration
+
+	// ZoneChanged is called when the zone of a device changes. It is called
+	// without the estimator lock held.
+	ZoneChanged func(Estimate)
+}
+
+// Estimator keeps the proximity of devices from a stream of RSSI samples,
+// such as scan results.
+type Estimator struct {
+	options     Options
+	lock        sync.Mutex
+	calibration map[string]Model
+	devices     map[string]*Estimate
+	now         func() time.Time
+}
+
+// NewEstimator returns a new Estimator.
+func NewEstimator(options Options) *Estimator {
+	if options.Model.Exponent == 0 {
+		options.Model = DefaultModel
+	}
+	if options.Zones == (Zones{}) {
+		options.Zones = DefaultZones
+	}
+	if options.Alpha <= 0 || options.Alpha > 1 {
+		options.Alpha = 0.25
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	if options.TTL <= 0 {
+		options.TTL = time.Minute
+	}
+	if options.TTL < options.Timeout {
+		options.TTL = options.Timeout
+	}
+	return &Estimator{
+		options:     options,
+		calibration: make(map[string]Model),
+		devices:     make(map[string]*Estimate),
+		now:         time.Now,
+	}
+}
+
+// Calibrate sets the model of the device with the given address, for example
+// one from Calibrate or from the measured power of an iBeacon.
+func (e *Estimator) Calibrate(address string, model Model) {
+	e.lock.Lock()
+	e.calibration[address] = model
+	e.lock.Unlock()
+}
+
+// HandleScanResult adds the RSSI of a scan result. It has the signature of
+// the Scan callback, so it can be passed to Scan directly.
+func (e *Estimator) HandleScanResult(adapter *bluetooth.Adapter, result bluetooth.ScanResult) {
+	e.AddScanResult(result)
+}
+
+// AddScanResult adds the RSSI of a scan result and returns the new estimate.
+// The advertised transmit power is used if the device is not calibrated. It
+// returns false if the scan result has no RSSI.
+func (e *Estimator) AddScanResult(result bluetooth.ScanResult) (Estimate, bool) {
+	if result.RSSI == 0 || result.Address == nil {
+		return Estimate{}, false
+	}
+	model, ok := e.model(result.Address.String())
+	if !ok && result.AdvertisementPayload != nil {
+		if txPower, ok := result.TxPower(); ok {
+			model = ModelFromTxPower(txPower)
+		}
+	}
+	return e.add(result.Address.String(), float64(result.RSSI), model), true
+}
+
+// Add adds an RSSI sample of the device with the given address and returns
+// the new estimate.
+func (e *Estimator) Add(address string, rssi float64) Estimate {
+	model, _ := e.model(address)
+	return e.add(address, rssi, model)
+}
+
+// model returns the calibrated model of the device, or the default model and
+// false.
+func (e *Estimator) model(address string) (Model, bool) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	model, ok := e.calibration[address]
+	if !ok {
+		return e.options.Model, false
+	}
+	return model, true
+}
+
+// add updates the estimate of a device with a new sample.
+func (e *Estimator) add(address string, rssi float64, model Model) Estimate {
+	e.lock.Lock()
+	est, ok := e.devices[address]
+	if !ok {
+		est = &Estimate{Address: address, RSSI: rssi}
+		e.devices[address] = est
+	} else if est.Zone == ZoneUnknown {
+		// Start over after the device was gone.
+		est.RSSI = rssi
+	} else {
+		est.RSSI += e.options.Alpha * (rssi - est.RSSI)
+	}
+	previous := est.Zone
+	est.Distance = model.Distance(est.RSSI)
+	est.Zone = e.options.Zones.Classify(est.Distance, previous)
+	est.Time = e.now()
+	result := *est
+	e.lock.Unlock()
+
+	if result.Zone != previous && e.options.ZoneChanged != nil {
+		e.options.ZoneChanged(result)
+	}
+	return result
+}
+
+// Get returns the current estimate of the device with the given address.
+func (e *Estimator) Get(address string) (Estimate, bool) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	est, ok := e.devices[address]
+	if !ok {
+		return Estimate{}, false
+	}
+	return *est, true
+}
+
+// Forget removes the estimate of the device with the given address. Its
+// calibration is kept.
+func (e *Estimator) Forget(address string) {
+	e.lock.Lock()
+	delete(e.devices, address)
+	e.lock.Unlock()
+}
+
+// Len returns the number of devices with an estimate.
+func (e *Estimator) Len() int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return len(e.devices)
+}
+
+// Expire moves devices that have not been seen for the timeout to
+// ZoneUnknown and returns them. Devices that have not been seen for the TTL
+// are removed. Call it regularly.
+func (e *Estimator) Expire() []Estimate {
+	now := e.now()
+	var expired []Estimate
+	e.lock.Lock()
+	for address, est := range e.devices {
+		if est.Zone != ZoneUnknown && now.Sub(est.Time) > e.options.Timeout {
+			est.Zone = ZoneUnknown
+			expired = append(expired, *est)
+		}
+		if now.Sub(est.Time) > e.options.TTL {
+			delete(e.devices, address)
+		}
+	}
+	e.lock.Unlock()
+
+	if e.options.ZoneChanged != nil {
+		for _, est := range expired {
+			e.options.ZoneChanged(est)
+		}
+	}
+	return expired
+}
@@ -0,0 +1,126 @@
+package proximity
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestModel(t *testing.T) {
+	m := Model{MeasuredPower: -59, Exponent: 2}
+	if d := m.Distance(-59); math.Abs(d-1) > 1e-9 {
+		t.Errorf("expected 1 m at the measured power, got %v", d)
+	}
+	if d := m.Distance(-79); math.Abs(d-10) > 1e-9 {
+		t.Errorf("expected 10 m at 20 dB below the measured power, got %v", d)
+	}
+	for _, distance := range []float64{0.1, 1, 2.5, 40} {
+		if d := m.Distance(m.RSSI(distance)); math.Abs(d-distance) > 1e-9 {
+			t.Errorf("round trip of %v m gave %v m", distance, d)
+		}
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	want := Model{MeasuredPower: -63, Exponent: 2.7}
+	var samples []Sample
+	for _, distance := range []float64{0.5, 1, 2, 4, 8} {
+		samples = append(samples, Sample{distance, want.RSSI(distance)})
+	}
+	got, err := Calibrate(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(got.MeasuredPower-want.MeasuredPower) > 1e-6 || math.Abs(got.Exponent-want.Exponent) > 1e-6 {
+		t.Errorf("Calibrate: expected %+v, got %+v", want, got)
+	}
+
+	got, err = Calibrate([]Sample{{1, -60}, {1, -62}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MeasuredPower != -61 || got.Exponent != DefaultModel.Exponent {
+		t.Errorf("Calibrate at 1 m: unexpected %+v", got)
+	}
+
+	if _, err := Calibrate(nil); err == nil {
+		t.Error("expected an error without samples")
+	}
+}
+
+func TestZonesHysteresis(t *testing.T) {
+	z := Zones{Immediate: 0.5, Near: 3, Hysteresis: 0.2}
+	steps := []struct {
+		distance float64
+		zone     Zone
+	}{
+		{0.3, ZoneImmediate},
+		{0.55, ZoneImmediate}, // within hysteresis of the immediate bound
+		{0.7, ZoneNear},
+		{0.45, ZoneNear}, // within hysteresis on the way back
+		{0.35, ZoneImmediate},
+		{3.5, ZoneNear}, // within hysteresis of the near bound
+		{3.7, ZoneFar},
+		{2.6, ZoneFar},
+		{2.3, ZoneNear},
+	}
+	zone := ZoneUnknown
+	for _, step := range steps {
+		zone = z.Classify(step.distance, zone)
+		if zone != step.zone {
+			t.Errorf("Classify(%v): expected %v, got %v", step.distance, step.zone, zone)
+		}
+	}
+	if zone := z.Classify(math.Inf(1), ZoneNear); zone != ZoneUnknown {
+		t.Errorf("expected an infinite distance to be unknown, got %v", zone)
+	}
+}
+
+func TestEstimatorExpire(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var changes []Estimate
+	e := NewEstimator(Options{
+		Timeout:     10 * time.Second,
+		TTL:         time.Minute,
+		ZoneChanged: func(est Estimate) { changes = append(changes, est) },
+	})
+	e.now = func() time.Time { return now }
+
+	e.Add("a", -60)
+	e.Add("b", -60)
+	now = now.Add(5 * time.Second)
+	e.Add("b", -60)
+
+	now = now.Add(6 * time.Second)
+	expired := e.Expire()
+	if len(expired) != 1 || expired[0].Address != "a" || expired[0].Zone != ZoneUnknown {
+		t.Fatalf("expected a to expire, got %+v", expired)
+	}
+	if e.Len() != 2 {
+		t.Errorf("expected 2 devices before the TTL, got %d", e.Len())
+	}
+
+	// a is removed after the TTL, b only a few seconds later.
+	now = now.Add(50 * time.Second)
+	e.Expire()
+	if _, ok := e.Get("a"); ok {
+		t.Error("a not removed after the TTL")
+	}
+	if _, ok := e.Get("b"); !ok {
+		t.Error("b removed before the TTL")
+	}
+	now = now.Add(5 * time.Second)
+	e.Expire()
+	if e.Len() != 0 {
+		t.Errorf("expected no devices after the TTL, got %d", e.Len())
+	}
+
+	e.Add("c", -60)
+	e.Forget("c")
+	if _, ok := e.Get("c"); ok || e.Len() != 0 {
+		t.Error("c not forgotten")
+	}
+	if len(changes) != 5 {
+		t.Errorf("expected 5 zone changes, got %d", len(changes))
+	}
+}
@@ -0,0 +1,499 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// This file implements a single dispatcher for the signals of the system bus
+// connection. godbus sends every signal to every channel registered with
+// Conn.Signal, so instead of one channel per watcher, there is one channel
+// for the whole package. Signals are routed to subscribers by object path and
+// name, which stays cheap with hundreds of watched devices, and the match
+// rules are shared and reference counted.
+
+// signalFilter selects the signals of a subscription.
+type signalFilter struct {
+	// Sender is the bus name that sends the signals. The default is
+	// org.bluez.
+	Sender string
+
+	// Path is the object that sends the signals. With Namespace, objects
+	// below it are included too.
+	Path      dbus.ObjectPath
+	Namespace bool
+
+	// Interface and Member are the name of the signal. Without Member, all
+	// signals of the interface are selected.
+	Interface string
+	Member    string
+
+	// Arg0 restricts the signals to those with this string as first
+	// argument, as in the NameOwnerChanged signal. Optional.
+	Arg0 string
+
+	// Arg0Path restricts the signals to those with an object path below it
+	// as first argument, as in the InterfacesAdded signal. Optional.
+	Arg0Path dbus.ObjectPath
+
+	// Object restricts the signals to those about this object, which is the
+	// first argument, as in the InterfacesRemoved signal. Optional.
+	Object dbus.ObjectPath
+}
+
+// name returns the full name of the signals, as in dbus.Signal.Name.
+func (f signalFilter) name() string {
+	return f.Interface + "." + f.Member
+}
+
+// matchOptions returns the match rule for the filter. For a single object
+// below an adapter, the rule covers the whole adapter so that the rule is
+// shared by all objects of the adapter, and the signals are filtered by the
+// router instead.
+func (f signalFilter) matchOptions() []dbus.MatchOption {
+	sender := f.Sender
+	if sender == "" {
+		sender = "org.bluez"
+	}
+	options := []dbus.MatchOption{
+		dbus.WithMatchSender(sender),
+		dbus.WithMatchInterface(f.Interface),
+	}
+	if f.Member != "" {
+		options = append(options, dbus.WithMatchMember(f.Member))
+	}
+	switch {
+	case f.Path == "":
+	case f.Namespace:
+		options = append(options, dbus.WithMatchPathNamespace(f.Path))
+	case adapterNamespace(f.Path) != "":
+		options = append(options, dbus.WithMatchPathNamespace(adapterNamespace(f.Path)))
+	default:
+		options = append(options, dbus.WithMatchObjectPath(f.Path))
+	}
+	if f.Arg0 != "" {
+		options = append(options, dbus.WithMatchOption("arg0", f.Arg0))
+	}
+	if f.Arg0Path != "" {
+		options = append(options, dbus.WithMatchOption("arg0path", string(f.Arg0Path)+"/"))
+	}
+	switch {
+	case f.Object == "":
+	case adapterNamespace(f.Object) != "":
+		// Shared by all objects of the adapter, like the path above.
+		options = append(options, dbus.WithMatchOption("arg0path", string(adapterNamespace(f.Object))+"/"))
+	default:
+		options = append(options, dbus.WithMatchOption("arg0path", string(f.Object)))
+	}
+	return options
+}
+
+// routeTable is the table of the router that a filter is kept in.
+type routeTable uint8
+
+const (
+	routeOthers   routeTable = iota // checked for every signal
+	routeByPath                     // found by the path of the signal
+	routeByObject                   // found by the object in the first argument
+)
+
+// route returns where the router keeps subscribers of the filter, so that
+// filters for a single object are found with a map lookup.
+func (f signalFilter) route() (routeKey, routeTable) {
+	if f.Namespace || f.Member == "" || f.Arg0 != "" || f.Arg0Path != "" {
+		return routeKey{}, routeOthers
+	}
+	if f.Object != "" {
+		return routeKey{f.Object, f.name()}, routeByObject
+	}
+	if f.Path != "" {
+		return routeKey{f.Path, f.name()}, routeByPath
+	}
+	return routeKey{}, routeOthers
+}
+
+// matches returns whether the signal is selected by the filter. The sender
+// is left to the match rule, as signals carry the unique name of the sender.
+func (f signalFilter) matches(sig *dbus.Signal) bool {
+	if f.Member == "" {
+		if !strings.HasPrefix(sig.Name, f.Interface+".") {
+			return false
+		}
+	} else if sig.Name != f.name() {
+		return false
+	}
+	if f.Path != "" && sig.Path != f.Path && !(f.Namespace && isBelow(sig.Path, f.Path)) {
+		return false
+	}
+	if f.Arg0 != "" {
+		if len(sig.Body) < 1 {
+			return false
+		}
+		if arg0, ok := sig.Body[0].(string); !ok || arg0 != f.Arg0 {
+			return false
+		}
+	}
+	if f.Arg0Path != "" {
+		if len(sig.Body) < 1 {
+			return false
+		}
+		path, ok := sig.Body[0].(dbus.ObjectPath)
+		if !ok || !isBelow(path, f.Arg0Path) {
+			return false
+		}
+	}
+	if f.Object != "" {
+		if len(sig.Body) < 1 {
+			return false
+		}
+		if path, ok := sig.Body[0].(dbus.ObjectPath); !ok || path != f.Object {
+			return false
+		}
+	}
+	return true
+}
+
+// isBelow returns whether path is an object below parent.
+func isBelow(path, parent dbus.ObjectPath) bool {
+	if parent == "/" {
+		return true
+	}
+	return strings.HasPrefix(string(path), string(parent)+"/")
+}
+
+// adapterNamespace returns the path of the adapter that the object belongs
+// to, such as /org/bluez/hci0, or "" if it is not an adapter or below one.
+func adapterNamespace(path dbus.ObjectPath) dbus.ObjectPath {
+	const prefix = "/org/bluez/hci"
+	s := string(path)
+	if !strings.HasPrefix(s, prefix) {
+		return ""
+	}
+	if i := strings.IndexByte(s[len(prefix):], '/'); i >= 0 {
+		return dbus.ObjectPath(s[:len(prefix)+i])
+	}
+	return path
+}
+
+// routeKey is the key of subscriptions to a single object.
+type routeKey struct {
+	path dbus.ObjectPath
+	name string
+}
+
+// signalRouter dispatches the signals of the system bus connection.
+type signalRouter struct {
+	lock    sync.Mutex
+	started bool
+	bus     *dbus.Conn
+	rules   map[string]int                              // reference counts of match rules
+	subs    int                                         // number of subscriptions
+	dropped uint64                                      // signals dropped by full queues
+	paths   map[routeKey]map[*signalSubscriber]struct{} // routeByPath
+	objects map[routeKey]map[*signalSubscriber]struct{} // routeByObject
+	others  map[*signalSubscriber]struct{}              // routeOthers
+}
+
+// router is the dispatcher of the system bus connection.
+var router = newSignalRouter()
+
+// newSignalRouter returns a router that is not connected to the bus yet.
+func newSignalRouter() signalRouter {
+	return signalRouter{
+		rules:   make(map[string]int),
+		paths:   make(map[routeKey]map[*signalSubscriber]struct{}),
+		objects: make(map[routeKey]map[*signalSubscriber]struct{}),
+		others:  make(map[*signalSubscriber]struct{}),
+	}
+}
+
+// maxQueuedSignals is the number of signals queued for a subscriber that does
+// not keep up. Beyond that the oldest signals are dropped.
+const maxQueuedSignals = 512
+
+// signalSubscriber queues the signals of one subscription, so that a slow
+// subscriber does not hold up the others and signals stay in order.
+type signalSubscriber struct {
+	filters []signalFilter
+	out     chan *dbus.Signal
+	wake    chan struct{}
+	done    chan struct{}
+
+	lock  sync.Mutex
+	queue []*dbus.Signal
+}
+
+// watchSignals subscribes to the signals selected by any of the filters. The
+// channel only receives those signals, in the order they were sent, and is
+// closed by the returned function, which removes the subscription.
+func watchSignals(filters ...signalFilter) (<-chan *dbus.Signal, func(), error) {
+	return router.subscribe(filters)
+}
+
+// watchPropertiesChanged subscribes to PropertiesChanged signals of a single
+// BlueZ object.
+func watchPropertiesChanged(path dbus.ObjectPath) (<-chan *dbus.Signal, func(), error) {
+	return watchSignals(signalFilter{
+		Path:      path,
+		Interface: "org.freedesktop.DBus.Properties",
+		Member:    "PropertiesChanged",
+	})
+}
+
+// subscribe adds a subscription and its match rules.
+func (r *signalRouter) subscribe(filters []signalFilter) (<-chan *dbus.Signal, func(), error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.started {
+		bus, err := dbus.SystemBus()
+		if err != nil {
+			return nil, nil, err
+		}
+		// godbus does not block when this channel is full but delivers from
+		// new goroutines, which loses the order, so keep plenty of room.
+		in := make(chan *dbus.Signal, 1024)
+		bus.Signal(in)
+		go r.run(in)
+		r.bus = bus
+		r.started = true
+	}
+
+	for i, filter := range filters {
+		err := r.addRule(filter.matchOptions())
+		if err != nil {
+			for _, added := range filters[:i] {
+				r.removeRule(added.matchOptions())
+			}
+			return nil, nil, err
+		}
+	}
+
+	sub := &signalSubscriber{
+		filters: filters,
+		out:     make(chan *dbus.Signal),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	r.index(sub)
+	r.subs++
+	go sub.pump()
+
+	var once sync.Once
+	return sub.out, func() {
+		once.Do(func() {
+			r.unsubscribe(sub)
+		})
+	}, nil
+}
+
+// unsubscribe removes a subscription, and the match rules that no other
+// subscription uses.
+func (r *signalRouter) unsubscribe(sub *signalSubscriber) {
+	r.lock.Lock()
+	r.subs--
+	r.unindex(sub)
+	for _, filter := range sub.filters {
+		r.removeRule(filter.matchOptions())
+	}
+	r.lock.Unlock()
+	close(sub.done)
+}
+
+// index adds the subscriber to the routing tables. It must be called with the
+// lock held.
+func (r *signalRouter) index(sub *signalSubscriber) {
+	for _, filter := range sub.filters {
+		key, table := filter.route()
+		var tables map[routeKey]map[*signalSubscriber]struct{}
+		switch table {
+		case routeByPath:
+			tables = r.paths
+		case routeByObject:
+			tables = r.objects
+		default:
+			r.others[sub] = struct{}{}
+			continue
+		}
+		if tables[key] == nil {
+			tables[key] = make(map[*signalSubscriber]struct{})
+		}
+		tables[key][sub] = struct{}{}
+	}
+}
+
+// unindex removes the subscriber from the routing tables. It must be called
+// with the lock held.
+func (r *signalRouter) unindex(sub *signalSubscriber) {
+	delete(r.others, sub)
+	for _, filter := range sub.filters {
+		key, table := filter.route()
+		tables := r.paths
+		if table == routeByObject {
+			tables = r.objects
+		}
+		if subs := tables[key]; subs != nil {
+			delete(subs, sub)
+			if len(subs) == 0 {
+				delete(tables, key)
+			}
+		}
+	}
+}
+
+// addRule adds a match rule to the bus, unless it was added before. It must
+// be called with the lock held.
+func (r *signalRouter) addRule(options []dbus.MatchOption) error {
+	rule := matchRule(options)
+	if r.rules[rule] == 0 {
+		err := r.bus.AddMatchSignal(options...)
+		if err != nil {
+			return err
+		}
+	}
+	r.rules[rule]++
+	return nil
+}
+
+// removeRule removes a match rule from the bus once it is no longer used. It
+// must be called with the lock held.
+func (r *signalRouter) removeRule(options []dbus.MatchOption) {
+	rule := matchRule(options)
+	r.rules[rule]--
+	if r.rules[rule] <= 0 {
+		delete(r.rules, rule)
+		r.bus.RemoveMatchSignal(options...)
+	}
+}
+
+// stats returns the number of subscriptions, of match rules on the bus and
+// of signals dropped because a subscriber did not keep up.
+func (r *signalRouter) stats() (subscriptions, rules int, dropped uint64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.subs, len(r.rules), r.dropped
+}
+
+// matchRule returns a key for a match rule. MatchOption has no exported
+// fields, but fmt prints them.
+func matchRule(options []dbus.MatchOption) string {
+	return fmt.Sprint(options)
+}
+
+// run dispatches the signals of the connection until it is closed.
+func (r *signalRouter) run(in chan *dbus.Signal) {
+	for sig := range in {
+		r.dispatch(sig)
+	}
+}
+
+// dispatch sends a signal to the subscribers it matches. Most subscribers
+// watch single objects and are found with one map lookup.
+func (r *signalRouter) dispatch(sig *dbus.Signal) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	pushed := r.paths[routeKey{sig.Path, sig.Name}]
+	for sub := range pushed {
+		r.push(sub, sig)
+	}
+	var byObject map[*signalSubscriber]struct{}
+	if len(sig.Body) > 0 {
+		if path, ok := sig.Body[0].(dbus.ObjectPath); ok {
+			byObject = r.objects[routeKey{path, sig.Name}]
+		}
+	}
+	for sub := range byObject {
+		if _, ok := pushed[sub]; !ok && sub.matches(sig) {
+			r.push(sub, sig)
+		}
+	}
+	for sub := range r.others {
+		_, byPath := pushed[sub]
+		_, ok := byObject[sub]
+		if !byPath && !ok && sub.matches(sig) {
+			r.push(sub, sig)
+		}
+	}
+}
+
+// push queues a signal for a subscriber and counts the signal it dropped, if
+// any. The lock must be held.
+func (r *signalRouter) push(sub *signalSubscriber, sig *dbus.Signal) {
+	if !sub.push(sig) {
+		r.dropped++
+	}
+}
+
+// matches returns whether the signal is selected by any filter of the
+// subscriber.
+func (s *signalSubscriber) matches(sig *dbus.Signal) bool {
+	for _, filter := range s.filters {
+		if filter.matches(sig) {
+			return true
+		}
+	}
+	return false
+}
+
+// push queues a signal for the subscriber. When the queue is full, the
+// oldest signal is dropped and push returns false.
+func (s *signalSubscriber) push(sig *dbus.Signal) bool {
+	s.lock.Lock()
+	ok := len(s.queue) < maxQueuedSignals
+	if !ok {
+		s.queue = s.queue[1:]
+	}
+	s.queue = append(s.queue, sig)
+	s.lock.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return ok
+}
+
+// pump sends the queued signals to the subscriber until the subscription is
+// removed, and then closes the channel.
+func (s *signalSubscriber) pump() {
+	defer close(s.out)
+	for {
+		s.lock.Lock()
+		queue := s.queue
+		s.queue = nil
+		s.lock.Unlock()
+		for _, sig := range queue {
+			select {
+			case s.out <- sig:
+			case <-s.done:
+				return
+			}
+		}
+		select {
+		case <-s.wake:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// propertiesChangedFor returns the changed properties in the given signal if
+// it is a PropertiesChanged signal for the given object path and interface.
+func propertiesChangedFor(sig *dbus.Signal, path dbus.ObjectPath, iface string) (map[string]dbus.Variant, bool) {
+	if sig == nil || sig.Path != path || sig.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" {
+		return nil, false
+	}
+	if len(sig.Body) < 2 {
+		return nil, false
+	}
+	interfaceName, ok := sig.Body[0].(string)
+	if !ok || interfaceName != iface {
+		return nil, false
+	}
+	changes, ok := sig.Body[1].(map[string]dbus.Variant)
+	return changes, ok
+}
@@ -0,0 +1,243 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestSignalFilter(t *testing.T) {
+	changed := func(path dbus.ObjectPath) *dbus.Signal {
+		return &dbus.Signal{Path: path, Name: "org.freedesktop.DBus.Properties.PropertiesChanged"}
+	}
+	added := func(path dbus.ObjectPath) *dbus.Signal {
+		return &dbus.Signal{Path: "/", Name: "org.freedesktop.DBus.ObjectManager.InterfacesAdded", Body: []interface{}{path}}
+	}
+	device := signalFilter{
+		Path:      "/org/bluez/hci0/dev_00_11_22_33_44_55",
+		Interface: "org.freedesktop.DBus.Properties",
+		Member:    "PropertiesChanged",
+	}
+	namespace := signalFilter{
+		Path:      "/org/bluez/hci0",
+		Namespace: true,
+		Interface: "org.freedesktop.DBus.Properties",
+		Member:    "PropertiesChanged",
+	}
+	objects := signalFilter{
+		Path:      "/",
+		Interface: "org.freedesktop.DBus.ObjectManager",
+		Arg0Path:  "/org/bluez/hci0",
+	}
+	tests := []struct {
+		filter signalFilter
+		sig    *dbus.Signal
+		match  bool
+	}{
+		{device, changed("/org/bluez/hci0/dev_00_11_22_33_44_55"), true},
+		{device, changed("/org/bluez/hci0/dev_00_11_22_33_44_66"), false},
+		{device, added("/org/bluez/hci0/dev_00_11_22_33_44_55"), false},
+		{namespace, changed("/org/bluez/hci0"), true},
+		{namespace, changed("/org/bluez/hci0/dev_00_11_22_33_44_55"), true},
+		{namespace, changed("/org/bluez/hci01"), false},
+		{namespace, changed("/org/bluez/hci1/dev_00_11_22_33_44_55"), false},
+		{objects, added("/org/bluez/hci0/dev_00_11_22_33_44_55"), true},
+		{objects, added("/org/bluez/hci1/dev_00_11_22_33_44_55"), false},
+		{objects, added("/org/bluez/hci0"), false},
+		{objects, &dbus.Signal{Path: "/", Name: "org.freedesktop.DBus.ObjectManager.InterfacesAdded"}, false},
+	}
+	for _, tc := range tests {
+		if match := tc.filter.matches(tc.sig); match != tc.match {
+			t.Errorf("%+v matches %s %s: got %v", tc.filter, tc.sig.Path, tc.sig.Name, match)
+		}
+	}
+
+	removed := signalFilter{
+		Path:      "/",
+		Interface: "org.freedesktop.DBus.ObjectManager",
+		Member:    "InterfacesRemoved",
+		Object:    "/org/bluez/hci0/dev_00_11_22_33_44_55",
+	}
+	removedSignal := func(path dbus.ObjectPath) *dbus.Signal {
+		return &dbus.Signal{Path: "/", Name: "org.freedesktop.DBus.ObjectManager.InterfacesRemoved", Body: []interface{}{path}}
+	}
+	if !removed.matches(removedSignal("/org/bluez/hci0/dev_00_11_22_33_44_55")) ||
+		removed.matches(removedSignal("/org/bluez/hci0/dev_00_11_22_33_44_66")) {
+		t.Error("wrong matches for InterfacesRemoved of one object")
+	}
+
+	routes := []struct {
+		filter signalFilter
+		table  routeTable
+	}{
+		{device, routeByPath},
+		{namespace, routeOthers},
+		{objects, routeOthers},
+		{removed, routeByObject},
+	}
+	for _, tc := range routes {
+		if _, table := tc.filter.route(); table != tc.table {
+			t.Errorf("%+v: expected route %d, got %d", tc.filter, tc.table, table)
+		}
+	}
+
+	// All devices of an adapter share one match rule.
+	other := device
+	other.Path = "/org/bluez/hci0/dev_00_11_22_33_44_66/service000a/char000b"
+	if matchRule(device.matchOptions()) != matchRule(other.matchOptions()) {
+		t.Errorf("rules differ: %s, %s", matchRule(device.matchOptions()), matchRule(other.matchOptions()))
+	}
+	if matchRule(device.matchOptions()) != matchRule(namespace.matchOptions()) {
+		t.Errorf("rules differ: %s, %s", matchRule(device.matchOptions()), matchRule(namespace.matchOptions()))
+	}
+	other.Path = "/org/bluez/hci1/dev_00_11_22_33_44_55"
+	if matchRule(device.matchOptions()) == matchRule(other.matchOptions()) {
+		t.Errorf("rules of different adapters are equal: %s", matchRule(other.matchOptions()))
+	}
+	otherRemoved := removed
+	otherRemoved.Object = "/org/bluez/hci0/dev_00_11_22_33_44_66"
+	if matchRule(removed.matchOptions()) != matchRule(otherRemoved.matchOptions()) {
+		t.Errorf("rules differ: %s, %s", matchRule(removed.matchOptions()), matchRule(otherRemoved.matchOptions()))
+	}
+}
+
+func TestAdapterNamespace(t *testing.T) {
+	tests := map[dbus.ObjectPath]dbus.ObjectPath{
+		"/org/bluez/hci0":                                   "/org/bluez/hci0",
+		"/org/bluez/hci12/dev_00_11_22_33_44_55":            "/org/bluez/hci12",
+		"/org/bluez/hci0/dev_00_11_22_33_44_55/service000a": "/org/bluez/hci0",
+		"/org/bluez": "",
+		"/":          "",
+	}
+	for path, expected := range tests {
+		if ns := adapterNamespace(path); ns != expected {
+			t.Errorf("adapterNamespace(%s): expected %s, got %s", path, expected, ns)
+		}
+	}
+}
+
+func TestSignalRouterDispatch(t *testing.T) {
+	r := newSignalRouter()
+	newSubscriber := func(filters ...signalFilter) *signalSubscriber {
+		sub := &signalSubscriber{
+			filters: filters,
+			out:     make(chan *dbus.Signal),
+			wake:    make(chan struct{}, 1),
+			done:    make(chan struct{}),
+		}
+		r.index(sub)
+		go sub.pump()
+		return sub
+	}
+	devicePath := dbus.ObjectPath("/org/bluez/hci0/dev_00_11_22_33_44_55")
+	device := signalFilter{
+		Path:      devicePath,
+		Interface: "org.freedesktop.DBus.Properties",
+		Member:    "PropertiesChanged",
+	}
+	namespace := device
+	namespace.Path = "/org/bluez/hci0"
+	namespace.Namespace = true
+
+	single := newSubscriber(device)
+	both := newSubscriber(device, namespace)
+	defer close(single.done)
+	defer close(both.done)
+
+	const n = 100
+	for i := 0; i < n; i++ {
+		r.dispatch(&dbus.Signal{Path: devicePath, Name: "org.freedesktop.DBus.Properties.PropertiesChanged", Body: []interface{}{i}})
+	}
+	r.dispatch(&dbus.Signal{Path: "/org/bluez/hci0", Name: "org.freedesktop.DBus.Properties.PropertiesChanged", Body: []interface{}{n}})
+
+	// Signals arrive in order and only once, although the receivers were not
+	// ready when they were dispatched.
+	for i := 0; i < n; i++ {
+		if sig := <-single.out; sig.Body[0] != i {
+			t.Fatalf("single: expected signal %d, got %d", i, sig.Body[0])
+		}
+	}
+	for i := 0; i <= n; i++ {
+		if sig := <-both.out; sig.Body[0] != i {
+			t.Fatalf("both: expected signal %d, got %d", i, sig.Body[0])
+		}
+	}
+	select {
+	case sig := <-single.out:
+		t.Errorf("single: unexpected signal %d", sig.Body[0])
+	default:
+	}
+}
+
+func TestSignalRouterDispatchObject(t *testing.T) {
+	r := newSignalRouter()
+	path := dbus.ObjectPath("/org/bluez/hci0/dev_00_11_22_33_44_55")
+	sub := &signalSubscriber{
+		filters: []signalFilter{{
+			Path:      path,
+			Interface: "org.freedesktop.DBus.Properties",
+			Member:    "PropertiesChanged",
+		}, {
+			Path:      "/",
+			Interface: "org.freedesktop.DBus.ObjectManager",
+			Member:    "InterfacesRemoved",
+			Object:    path,
+		}},
+		out:  make(chan *dbus.Signal),
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	r.index(sub)
+	go sub.pump()
+	defer close(sub.done)
+
+	removed := func(path dbus.ObjectPath) *dbus.Signal {
+		return &dbus.Signal{
+			Path: "/",
+			Name: "org.freedesktop.DBus.ObjectManager.InterfacesRemoved",
+			Body: []interface{}{path, []string{"org.bluez.Device1"}},
+		}
+	}
+	r.dispatch(removed("/org/bluez/hci0/dev_00_11_22_33_44_66"))
+	r.dispatch(removed(path))
+	sig := <-sub.out
+	if !isDeviceRemoved(sig, path) {
+		t.Errorf("expected the removal of %s, got %v", path, sig.Body)
+	}
+
+	r.unindex(sub)
+	if len(r.paths) != 0 || len(r.objects) != 0 || len(r.others) != 0 {
+		t.Errorf("routes left after unindex: %d, %d, %d", len(r.paths), len(r.objects), len(r.others))
+	}
+}
+
+func TestSignalRouterDropsOldest(t *testing.T) {
+	r := newSignalRouter()
+	path := dbus.ObjectPath("/org/bluez/hci0/dev_00_11_22_33_44_55")
+	sub := &signalSubscriber{
+		filters: []signalFilter{{
+			Path:      path,
+			Interface: "org.freedesktop.DBus.Properties",
+			Member:    "PropertiesChanged",
+		}},
+		out:  make(chan *dbus.Signal),
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	r.index(sub)
+
+	// Nobody receives, so the queue fills up.
+	const extra = 10
+	for i := 0; i < maxQueuedSignals+extra; i++ {
+		r.dispatch(&dbus.Signal{Path: path, Name: "org.freedesktop.DBus.Properties.PropertiesChanged", Body: []interface{}{i}})
+	}
+	if _, _, dropped := r.stats(); dropped != extra {
+		t.Errorf("expected %d dropped signals, got %d", extra, dropped)
+	}
+	if len(sub.queue) != maxQueuedSignals || sub.queue[0].Body[0] != extra {
+		t.Errorf("expected the newest %d signals, got %d starting at %v", maxQueuedSignals, len(sub.queue), sub.queue[0].Body[0])
+	}
+}
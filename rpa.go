@@ -0,0 +1,181 @@
+package bluetooth
+
+// This file implements resolving private addresses (RPAs) to identity
+// addresses, see the Bluetooth Core Specification, Vol 3, Part C, section
+// 10.8.2.3 and Vol 3, Part H, section 2.2.2.
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+)
+
+var errInvalidIRK = errors.New("bluetooth: failed to parse identity resolving key")
+
+// maxResolvedCache is the number of resolved addresses a Resolver remembers.
+// RPAs rotate every 15 minutes by default, so this is plenty for a few
+// hundred bonded devices.
+const maxResolvedCache = 1024
+
+// IRK is an identity resolving key. The bytes are in the order in which the
+// key is usually written, most significant byte first, as in BlueZ's info
+// files.
+type IRK [16]byte
+
+// ParseIRK parses an IRK written as 32 hex digits, most significant byte
+// first. A "0x" prefix is accepted.
+func ParseIRK(s string) (IRK, error) {
+	var irk IRK
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s) != 2*len(irk) {
+		return IRK{}, errInvalidIRK
+	}
+	if _, err := hex.Decode(irk[:], []byte(s)); err != nil {
+		return IRK{}, errInvalidIRK
+	}
+	return irk, nil
+}
+
+// String returns the IRK as 32 hex digits, most significant byte first.
+func (irk IRK) String() string {
+	return hex.EncodeToString(irk[:])
+}
+
+// Matches returns whether the given resolvable private address was generated
+// with this IRK.
+func (irk IRK) Matches(mac MAC) bool {
+	block, err := aes.NewCipher(irk[:])
+	if err != nil {
+		return false
+	}
+	return matchesRPA(block, mac)
+}
+
+// matchesRPA returns whether the hash in the lower 24 bits of mac matches the
+// random part in the upper 24 bits for the given key.
+func matchesRPA(block cipher.Block, mac MAC) bool {
+	if mac[5]>>6 != 0b01 {
+		// Not a resolvable private address.
+		return false
+	}
+	hash := ah(block, [3]byte{mac[5], mac[4], mac[3]})
+	return hash == [3]byte{mac[2], mac[1], mac[0]}
+}
+
+// ah is the random address hash function: the lower 24 bits of the AES-128
+// encryption of the zero-padded prand, with all values most significant byte
+// first.
+func ah(block cipher.Block, prand [3]byte) [3]byte {
+	var data [16]byte
+	copy(data[13:], prand[:])
+	block.Encrypt(data[:], data[:])
+	return [3]byte{data[13], data[14], data[15]}
+}
+
+// resolverKey is an IRK with the identity address it belongs to.
+type resolverKey struct {
+	identity MACAddress
+	irk      IRK
+	block    cipher.Block
+}
+
+// Resolver maps resolvable private addresses to the identity addresses of
+// the devices that generated them, using the IRKs exchanged during pairing.
+// It is safe for concurrent use.
+type Resolver struct {
+	lock     sync.RWMutex
+	keys     []resolverKey
+	resolved map[MAC]MACAddress
+}
+
+// NewResolver returns a Resolver without any keys.
+func NewResolver() *Resolver {
+	return &Resolver{
+		resolved: make(map[MAC]MACAddress),
+	}
+}
+
+// Add adds the IRK of the device with the given identity address. An
+// earlier IRK for the same identity is replaced.
+func (r *Resolver) Add(identity MACAddress, irk IRK) error {
+	block, err := aes.NewCipher(irk[:])
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.removeLocked(identity.MAC)
+	r.keys = append(r.keys, resolverKey{identity, irk, block})
+	return nil
+}
+
+// Remove removes the IRK of the device with the given identity address.
+func (r *Resolver) Remove(identity MAC) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.removeLocked(identity)
+}
+
+func (r *Resolver) removeLocked(identity MAC) {
+	for i, key := range r.keys {
+		if key.identity.MAC == identity {
+			r.keys = append(r.keys[:i], r.keys[i+1:]...)
+			break
+		}
+	}
+	for rpa, resolved := range r.resolved {
+		if resolved.MAC == identity {
+			delete(r.resolved, rpa)
+		}
+	}
+}
+
+// Len returns the number of IRKs in the resolver.
+func (r *Resolver) Len() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return len(r.keys)
+}
+
+// Resolve returns the identity address for the given address if it is a
+// resolvable private address generated with one of the known IRKs.
+func (r *Resolver) Resolve(address Addresser) (MACAddress, bool) {
+	if address == nil || address.Kind() != AddressResolvablePrivate {
+		return MACAddress{}, false
+	}
+	mac, err := ParseMAC(address.String())
+	if err != nil {
+		return MACAddress{}, false
+	}
+
+	r.lock.RLock()
+	identity, ok := r.resolved[mac]
+	r.lock.RUnlock()
+	if ok {
+		return identity, true
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, key := range r.keys {
+		if matchesRPA(key.block, mac) {
+			if len(r.resolved) >= maxResolvedCache {
+				r.resolved = make(map[MAC]MACAddress)
+			}
+			r.resolved[mac] = key.identity
+			return key.identity, true
+		}
+	}
+	return MACAddress{}, false
+}
+
+// Annotate sets the Identity of the scan result if its address resolves to
+// a known device.
+func (r *Resolver) Annotate(result *ScanResult) {
+	if identity, ok := r.Resolve(result.Address); ok {
+		result.Identity = identity
+	}
+}
@@ -0,0 +1,117 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/muka/go-bluetooth/bluez/profile/device"
+)
+
+// DefaultBlueZStorage is the directory where BlueZ stores the keys of paired
+// devices, in <adapter address>/<device address>/info files.
+const DefaultBlueZStorage = "/var/lib/bluetooth"
+
+// LoadBlueZ adds the IRKs of all devices paired with any adapter, as stored
+// by BlueZ in dir, or DefaultBlueZStorage if dir is empty. The files are
+// usually only readable by root. It returns the number of keys added and the
+// first error, if any; devices that cannot be read are skipped.
+func (r *Resolver) LoadBlueZ(dir string) (int, error) {
+	if dir == "" {
+		dir = DefaultBlueZStorage
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*", "info"))
+	if err != nil {
+		return 0, err
+	}
+	var firstErr error
+	n := 0
+	for _, path := range paths {
+		mac, err := ParseMAC(filepath.Base(filepath.Dir(path)))
+		if err != nil {
+			// Not a device directory, for example "cache".
+			continue
+		}
+		irk, addressType, ok, err := readBlueZInfo(path)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if !ok {
+			continue
+		}
+		identity := MACAddress{MAC: mac}
+		// BlueZ stores "static" for a random static identity address.
+		identity.SetRandom(addressType != "" && addressType != "public")
+		if err := r.Add(identity, irk); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, firstErr
+}
+
+// readBlueZInfo reads the IRK and the address type from a BlueZ info file.
+// ok is false if the file has no IRK.
+func readBlueZInfo(path string) (irk IRK, addressType string, ok bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		i := strings.IndexByte(line, '=')
+		if i < 0 {
+			continue
+		}
+		key, value := line[:i], line[i+1:]
+		switch {
+		case section == "General" && key == "AddressType":
+			addressType = value
+		case section == "IdentityResolvingKey" && key == "Key":
+			irk, err = ParseIRK(value)
+			if err != nil {
+				return
+			}
+			ok = true
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+// SetResolver sets the resolver used to fill in ScanResult.Identity for
+// devices that advertise with a resolvable private address. Pass nil to stop
+// resolving.
+func (a *Adapter) SetResolver(r *Resolver) {
+	a.resolverLock.Lock()
+	a.resolver = r
+	a.resolverLock.Unlock()
+}
+
+// makeScanResult creates a ScanResult from a Device1 object and resolves its
+// address if a resolver is set.
+func (a *Adapter) makeScanResult(props *device.Device1Properties) ScanResult {
+	result := makeScanResult(props)
+	a.resolverLock.Lock()
+	r := a.resolver
+	a.resolverLock.Unlock()
+	if r != nil {
+		r.Annotate(&result)
+	}
+	return result
+}
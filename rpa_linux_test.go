@@ -0,0 +1,45 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadBlueZ(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bluez")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"00:1A:7D:DA:71:13/C0:11:22:33:44:55/info":  "[General]\nName=Watch\nAddressType=static\n\n[IdentityResolvingKey]\nKey=EC0234A357C8AD05341010A60A397D9B\n",
+		"00:1A:7D:DA:71:13/11:22:33:44:55:66/info":  "[General]\nName=Speaker\nAddressType=public\n\n[LinkKey]\nKey=00112233445566778899AABBCCDDEEFF\n",
+		"00:1A:7D:DA:71:13/cache/11:22:33:44:55:66": "[General]\nName=Speaker\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewResolver()
+	n, err := r.LoadBlueZ(dir)
+	if n != 1 || err != nil {
+		t.Fatalf("expected 1 key but got %d, %v", n, err)
+	}
+	rpa, _ := ParseAddress("70:81:94:0D:FB:AA/random")
+	identity, ok := r.Resolve(rpa)
+	if !ok || identity.String() != "C0:11:22:33:44:55" || identity.Kind() != AddressRandomStatic {
+		t.Errorf("resolved to %s (%s), %v", identity, identity.Kind(), ok)
+	}
+}
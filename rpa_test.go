@@ -0,0 +1,75 @@
+package bluetooth
+
+import (
+	"crypto/aes"
+	"testing"
+)
+
+// Sample data from the Bluetooth Core Specification, Vol 3, Part H, appendix
+// D.7.
+var (
+	testIRK   = IRK{0xec, 0x02, 0x34, 0xa3, 0x57, 0xc8, 0xad, 0x05, 0x34, 0x10, 0x10, 0xa6, 0x0a, 0x39, 0x7d, 0x9b}
+	testPrand = [3]byte{0x70, 0x81, 0x94}
+	testHash  = [3]byte{0x0d, 0xfb, 0xaa}
+)
+
+func TestAh(t *testing.T) {
+	block, err := aes.NewCipher(testIRK[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash := ah(block, testPrand); hash != testHash {
+		t.Errorf("expected %x but got %x", testHash, hash)
+	}
+}
+
+func TestParseIRK(t *testing.T) {
+	irk, err := ParseIRK("EC0234A357C8AD05341010A60A397D9B")
+	if err != nil || irk != testIRK {
+		t.Errorf("expected %s but got %s, %v", testIRK, irk, err)
+	}
+	for _, s := range []string{"", "ec0234a357c8ad05341010a60a397d9", "xc0234a357c8ad05341010a60a397d9b"} {
+		if _, err := ParseIRK(s); err != errInvalidIRK {
+			t.Errorf("%q: expected errInvalidIRK but got %v", s, err)
+		}
+	}
+}
+
+func TestResolver(t *testing.T) {
+	rpa, _ := ParseAddress("70:81:94:0D:FB:AA/random")
+	if !testIRK.Matches(rpa.MAC) {
+		t.Error("IRK does not match the sample RPA")
+	}
+	other, _ := ParseAddress("70:81:94:0D:FB:AB/random")
+	if testIRK.Matches(other.MAC) {
+		t.Error("IRK matches an RPA with the wrong hash")
+	}
+
+	identity, _ := ParseAddress("C0:11:22:33:44:55/random")
+	r := NewResolver()
+	if _, ok := r.Resolve(rpa); ok {
+		t.Error("resolved without keys")
+	}
+	r.Add(identity, testIRK)
+	for i := 0; i < 2; i++ {
+		// The second time, the address comes from the cache.
+		resolved, ok := r.Resolve(rpa)
+		if !ok || resolved != identity {
+			t.Errorf("expected %s but got %s, %v", identity, resolved, ok)
+		}
+	}
+	if _, ok := r.Resolve(other); ok {
+		t.Error("resolved an RPA with the wrong hash")
+	}
+
+	result := ScanResult{Address: rpa}
+	r.Annotate(&result)
+	if result.Identity != identity {
+		t.Errorf("expected identity %s but got %v", identity, result.Identity)
+	}
+
+	r.Remove(identity.MAC)
+	if _, ok := r.Resolve(rpa); ok || r.Len() != 0 {
+		t.Error("resolved after the key was removed")
+	}
+}
@@ -0,0 +1,154 @@
+package bluetooth
+
+import (
+	"errors"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var errInvalidNameFilter = errors.New("bluetooth: invalid name filter mode")
+
+// NameMatchMode selects how a NameFilter compares names.
+type NameMatchMode uint8
+
+const (
+	// NameAny matches every name, including an empty one. It is the mode of
+	// the zero NameFilter.
+	NameAny NameMatchMode = iota
+
+	// NameExact matches names that are equal to the pattern.
+	NameExact
+
+	// NamePrefix matches names that start with the pattern.
+	NamePrefix
+
+	// NameGlob matches names with a glob pattern as used by path.Match, for
+	// example "M_*_TEST".
+	NameGlob
+
+	// NameRegexp matches names that contain a match of the regular
+	// expression. Use ^ and $ to match the whole name.
+	NameRegexp
+)
+
+// NameFilter matches the local name of a device, which is either the
+// complete or the shortened local name, depending on what the device
+// advertises. The zero value matches every name.
+type NameFilter struct {
+	mode    NameMatchMode
+	pattern string
+	re      *regexp.Regexp
+}
+
+// NewNameFilter returns a NameFilter with the given mode and pattern. It
+// returns an error if the pattern is not a valid glob pattern or regular
+// expression.
+func NewNameFilter(mode NameMatchMode, pattern string) (NameFilter, error) {
+	f := NameFilter{mode: mode, pattern: pattern}
+	switch mode {
+	case NameAny, NameExact, NamePrefix:
+	case NameGlob:
+		if _, err := path.Match(pattern, ""); err != nil {
+			return NameFilter{}, err
+		}
+	case NameRegexp:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return NameFilter{}, err
+		}
+		f.re = re
+	default:
+		return NameFilter{}, errInvalidNameFilter
+	}
+	return f, nil
+}
+
+// Mode returns the match mode of this filter.
+func (f NameFilter) Mode() NameMatchMode {
+	return f.mode
+}
+
+// Pattern returns the pattern of this filter.
+func (f NameFilter) Pattern() string {
+	return f.pattern
+}
+
+// Match returns whether the given name matches the filter. An empty name
+// only matches NameAny, so a device that has not sent its name yet does not
+// match until it does.
+func (f NameFilter) Match(name string) bool {
+	switch f.mode {
+	case NameAny:
+		return true
+	case NameExact:
+		return name == f.pattern
+	case NamePrefix:
+		return name != "" && strings.HasPrefix(name, f.pattern)
+	case NameGlob:
+		matched, _ := path.Match(f.pattern, name)
+		return matched && name != ""
+	case NameRegexp:
+		return name != "" && f.re.MatchString(name)
+	}
+	return false
+}
+
+// ScanMatch selects scan results by their advertisement. All non-zero fields
+// must match. The zero value matches every scan result.
+type ScanMatch struct {
+	// Name must match the local name of the device.
+	Name NameFilter
+
+	// ServiceUUIDs matches devices that advertise at least one of these
+	// services.
+	ServiceUUIDs []UUID
+
+	// ManufacturerIDs matches devices that send manufacturer data for at
+	// least one of these company identifiers.
+	ManufacturerIDs []uint16
+
+	// MinRSSI matches devices that are received with at least this signal
+	// strength, for example -80.
+	MinRSSI int16
+}
+
+// Matches returns whether the scan result matches. It can be passed where a
+// func(ScanResult) bool is expected.
+func (m ScanMatch) Matches(result ScanResult) bool {
+	if m.MinRSSI != 0 && (result.RSSI == 0 || result.RSSI < m.MinRSSI) {
+		return false
+	}
+	if result.AdvertisementPayload == nil {
+		return m.Name.Mode() == NameAny && len(m.ServiceUUIDs) == 0 && len(m.ManufacturerIDs) == 0
+	}
+	if !m.Name.Match(result.LocalName()) {
+		return false
+	}
+	if len(m.ServiceUUIDs) != 0 {
+		found := false
+		for _, uuid := range m.ServiceUUIDs {
+			if result.HasServiceUUID(uuid) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(m.ManufacturerIDs) != 0 {
+		manufacturerData := result.ManufacturerData()
+		found := false
+		for _, id := range m.ManufacturerIDs {
+			if _, ok := manufacturerData[id]; ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
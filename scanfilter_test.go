@@ -0,0 +1,48 @@
+package bluetooth
+
+import (
+	"testing"
+)
+
+func TestNameFilter(t *testing.T) {
+	tests := []struct {
+		mode    NameMatchMode
+		pattern string
+		name    string
+		match   bool
+	}{
+		{NameAny, "", "", true},
+		{NameAny, "", "M_SHANGHAI", true},
+		{NameExact, "M_SHANGHAI", "M_SHANGHAI", true},
+		{NameExact, "M_SHANGHAI", "M_SHANGHAI2", false},
+		{NamePrefix, "M_", "M_IZAR_TEST", true},
+		{NamePrefix, "M_", "", false},
+		{NamePrefix, "M_", "X_IZAR_TEST", false},
+		{NameGlob, "M_*_TEST", "M_IZAR_TEST", true},
+		{NameGlob, "M_*_TEST", "M_IZAR_ESP", false},
+		{NameGlob, "*", "", false},
+		{NameRegexp, "^M_(IZAR|SHANGHAI)$", "M_IZAR", true},
+		{NameRegexp, "^M_(IZAR|SHANGHAI)$", "M_IZAR_TEST", false},
+	}
+	for _, tc := range tests {
+		f, err := NewNameFilter(tc.mode, tc.pattern)
+		if err != nil {
+			t.Errorf("NewNameFilter(%d, %q): unexpected error %v", tc.mode, tc.pattern, err)
+			continue
+		}
+		if got := f.Match(tc.name); got != tc.match {
+			t.Errorf("NewNameFilter(%d, %q).Match(%q) = %v, expected %v", tc.mode, tc.pattern, tc.name, got, tc.match)
+		}
+	}
+
+	if _, err := NewNameFilter(NameGlob, "["); err == nil {
+		t.Error("expected an error for an invalid glob pattern")
+	}
+	if _, err := NewNameFilter(NameRegexp, "("); err == nil {
+		t.Error("expected an error for an invalid regular expression")
+	}
+	var zero NameFilter
+	if !zero.Match("anything") {
+		t.Error("expected the zero NameFilter to match every name")
+	}
+}
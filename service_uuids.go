@@ -1,5 +1,5 @@
 // Code generated by bin/gen-service-uuids; DO NOT EDIT.
-// This file was generated on 2021-02-01 14:19:12.31511038 +0100 CET m=+0.005731522 using the list of standard service UUIDs from
+// This file was generated on 2026-10-19 11:10:48.057905392 +0000 UTC m=+0.000978197 using the list of standard service UUIDs from
 // https://github.com/NordicSemiconductor/bluetooth-numbers-database/blob/master/v1/service_uuids.json
 //
 package bluetooth
@@ -205,3 +205,73 @@ var (
 	ServiceUUIDSMP = NewUUID([16]byte{0x8d,0x53,0xdc,0x1d,0x1d,0xb7,0x4c,0xd3,0x86,0x8b,0x8a,0x52,0x74,0x60,0xaa,0x84,})
 
 )
+
+// serviceUUIDNames maps the standard service UUIDs to their names.
+var serviceUUIDNames = map[UUID]string{
+	ServiceUUIDGenericAccess: "Generic Access",
+	ServiceUUIDAlertNotification: "Alert Notification Service",
+	ServiceUUIDAutomationIO: "Automation IO",
+	ServiceUUIDBattery: "Battery Service",
+	ServiceUUIDBloodPressure: "Blood Pressure",
+	ServiceUUIDBodyComposition: "Body Composition",
+	ServiceUUIDBondManagement: "Bond Management Service",
+	ServiceUUIDContinuousGlucoseMonitoring: "Continuous Glucose Monitoring",
+	ServiceUUIDCurrentTime: "Current Time Service",
+	ServiceUUIDCyclingPower: "Cycling Power",
+	ServiceUUIDCyclingSpeedAndCadence: "Cycling Speed and Cadence",
+	ServiceUUIDDeviceInformation: "Device Information",
+	ServiceUUIDEnvironmentalSensing: "Environmental Sensing",
+	ServiceUUIDFitnessMachine: "Fitness Machine",
+	ServiceUUIDGenericAttribute: "Generic Attribute",
+	ServiceUUIDGlucose: "Glucose",
+	ServiceUUIDHealthThermometer: "Health Thermometer",
+	ServiceUUIDHeartRate: "Heart Rate",
+	ServiceUUIDHTTPProxy: "HTTP Proxy",
+	ServiceUUIDHumanInterfaceDevice: "Human Interface Device",
+	ServiceUUIDImmediateAlert: "Immediate Alert",
+	ServiceUUIDIndoorPositioning: "Indoor Positioning",
+	ServiceUUIDInsulinDelivery: "Insulin Delivery",
+	ServiceUUIDInternetProtocolSupport: "Internet Protocol Support Service",
+	ServiceUUIDLinkLoss: "Link Loss",
+	ServiceUUIDLocationAndNavigation: "Location and Navigation",
+	ServiceUUIDMeshProvisioning: "Mesh Provisioning Service",
+	ServiceUUIDMeshProxy: "Mesh Proxy Service",
+	ServiceUUIDNextDSTChange: "Next DST Change Service",
+	ServiceUUIDObjectTransfer: "Object Transfer Service",
+	ServiceUUIDPhoneAlertStatus: "Phone Alert Status Service",
+	ServiceUUIDPulseOximeter: "Pulse Oximeter Service",
+	ServiceUUIDReconnectionConfiguration: "Reconnection Configuration",
+	ServiceUUIDReferenceTimeUpdate: "Reference Time Update Service",
+	ServiceUUIDRunningSpeedAndCadence: "Running Speed and Cadence",
+	ServiceUUIDScanParameters: "Scan Parameters",
+	ServiceUUIDTransportDiscovery: "Transport Discovery",
+	ServiceUUIDTxPower: "Tx Power",
+	ServiceUUIDUserData: "User Data",
+	ServiceUUIDWeightScale: "Weight Scale",
+	ServiceUUIDFirmwareRevision: "Firmware Revision",
+	ServiceUUIDAppleNotificationCenter: "Apple Notification Center Service",
+	ServiceUUIDAppleMedia: "Apple Media Service",
+	ServiceUUIDMicrobitAccelerometer: "micro:bit Accelerometer Service",
+	ServiceUUIDMicrobitMagnetometer: "micro:bit Magnetometer Service",
+	ServiceUUIDMicrobitButton: "micro:bit Button Service",
+	ServiceUUIDMicrobitIOPin: "micro:bit IO Pin Service",
+	ServiceUUIDMicrobitLED: "micro:bit LED Service",
+	ServiceUUIDMicrobitEvent: "micro:bit Event Service",
+	ServiceUUIDMicrobitDFUControl: "micro:bit DFU Control Service",
+	ServiceUUIDMicrobitTemperature: "micro:bit Temperature Service",
+	ServiceUUIDThingyConfiguration: "Thingy Configuration Service",
+	ServiceUUIDThingyWeatherStation: "Thingy Weather Station Service",
+	ServiceUUIDThingyUI: "Thingy UI Service",
+	ServiceUUIDThingyMotion: "Thingy Motion Service",
+	ServiceUUIDThingySound: "Thingy Sound Service",
+	ServiceUUIDNordicLEDAndButton: "Nordic LED and Button Service",
+	ServiceUUIDNordicUART: "Nordic UART Service",
+	ServiceUUIDEddystone: "Eddystone",
+	ServiceUUIDEddystoneConfiguration: "Eddystone Configuration Service",
+	ServiceUUIDFastPair: "Fast Pair Service",
+	ServiceUUIDLegacyDFU: "Legacy DFU Service",
+	ServiceUUIDSecureDFU: "Secure DFU Service",
+	ServiceUUIDExperimentalButtonlessDFU: "Experimental Buttonless DFU Service",
+	ServiceUUIDExposureNotification: "Exposure Notification Service",
+	ServiceUUIDSMP: "SMP Service",
+}
@@ -0,0 +1,292 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/muka/go-bluetooth/bluez/profile/adapter"
+)
+
+// ErrStackRestarted is returned by devices and characteristics that were
+// obtained before bluetoothd stopped or restarted. The objects they refer to
+// no longer exist; connect again to get new ones.
+var ErrStackRestarted = errors.New("bluetooth: BlueZ was restarted")
+
+// StackEventType is the type of a StackEvent.
+type StackEventType uint8
+
+const (
+	// StackStopped is sent when bluetoothd leaves the system bus, or is
+	// replaced by another process. All connections are gone at this point.
+	StackStopped StackEventType = iota + 1
+
+	// StackRestarted is sent when bluetoothd is back, after the adapters,
+	// advertisements and restart hooks have been set up again.
+	StackRestarted
+)
+
+// StackEvent is sent by WatchStack when bluetoothd stops or restarts.
+type StackEvent struct {
+	Type StackEventType
+
+	// Owner is the unique bus name of the new bluetoothd process, or "" if
+	// it stopped.
+	Owner string
+
+	// Errors are the errors that happened while setting up the adapters,
+	// advertisements and restart hooks again.
+	Errors []error
+}
+
+// stack tracks the bluetoothd process that owns the org.bluez name.
+var stack = struct {
+	lock     sync.Mutex
+	watching bool
+	owner    string
+	lost     chan struct{} // closed when org.bluez loses its owner
+	adapters map[*Adapter]struct{}
+	hooks    map[*stackHook]struct{}
+	watchers map[chan StackEvent]struct{}
+}{
+	lost:     make(chan struct{}),
+	adapters: make(map[*Adapter]struct{}),
+	hooks:    make(map[*stackHook]struct{}),
+	watchers: make(map[chan StackEvent]struct{}),
+}
+
+// stackHook is a function registered with OnStackRestarted.
+type stackHook struct {
+	fn func() error
+}
+
+// stackHandle records the bluetoothd process an object was obtained from.
+// The zero value is never stale.
+type stackHandle struct {
+	lost <-chan struct{}
+}
+
+// currentStack returns a handle for objects obtained from the current
+// bluetoothd process.
+func currentStack() stackHandle {
+	stack.lock.Lock()
+	defer stack.lock.Unlock()
+	return stackHandle{stack.lost}
+}
+
+// check returns ErrStackRestarted if bluetoothd stopped since the handle was
+// created.
+func (h stackHandle) check() error {
+	if h.lost == nil {
+		return nil
+	}
+	select {
+	case <-h.lost:
+		return ErrStackRestarted
+	default:
+		return nil
+	}
+}
+
+// OnStackRestarted registers a function that is called after bluetoothd
+// restarted, to register objects that BlueZ forgot, such as GATT applications
+// and agents registered outside of this package. Advertisements started with
+// Advertisement.Start are registered again automatically. The returned
+// function removes the hook.
+func OnStackRestarted(fn func() error) (remove func()) {
+	hook := &stackHook{fn}
+	stack.lock.Lock()
+	stack.hooks[hook] = struct{}{}
+	stack.lock.Unlock()
+	return func() {
+		stack.lock.Lock()
+		delete(stack.hooks, hook)
+		stack.lock.Unlock()
+	}
+}
+
+// WatchStack returns a channel that receives an event when bluetoothd stops
+// or restarts. The channel is closed when the context is canceled.
+func WatchStack(ctx context.Context) (<-chan StackEvent, error) {
+	if err := watchStack(); err != nil {
+		return nil, err
+	}
+	ch := make(chan StackEvent, 4)
+	stack.lock.Lock()
+	stack.watchers[ch] = struct{}{}
+	stack.lock.Unlock()
+	go func() {
+		<-ctx.Done()
+		stack.lock.Lock()
+		delete(stack.watchers, ch)
+		stack.lock.Unlock()
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// trackAdapter starts watching bluetoothd, so that the adapter proxy is
+// acquired again after a restart.
+func trackAdapter(a *Adapter) error {
+	stack.lock.Lock()
+	stack.adapters[a] = struct{}{}
+	stack.lock.Unlock()
+	return watchStack()
+}
+
+// untrackAdapter stops restoring the adapter after a restart.
+func untrackAdapter(a *Adapter) {
+	stack.lock.Lock()
+	delete(stack.adapters, a)
+	stack.lock.Unlock()
+}
+
+// watchStack starts watching the owner of the org.bluez name, once.
+func watchStack() error {
+	stack.lock.Lock()
+	defer stack.lock.Unlock()
+	if stack.watching {
+		return nil
+	}
+	bus, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	signal, _, err := watchSignals(signalFilter{
+		Sender:    "org.freedesktop.DBus",
+		Path:      "/org/freedesktop/DBus",
+		Interface: "org.freedesktop.DBus",
+		Member:    "NameOwnerChanged",
+		Arg0:      "org.bluez",
+	})
+	if err != nil {
+		return err
+	}
+	var owner string
+	err = bus.BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0, "org.bluez").Store(&owner)
+	if err != nil {
+		// bluetoothd is not running right now.
+		owner = ""
+	}
+	stack.owner = owner
+	stack.watching = true
+	go func() {
+		for sig := range signal {
+			if sig.Name != "org.freedesktop.DBus.NameOwnerChanged" || len(sig.Body) < 3 {
+				continue
+			}
+			name, _ := sig.Body[0].(string)
+			newOwner, _ := sig.Body[2].(string)
+			if name == "org.bluez" {
+				handleOwnerChanged(newOwner)
+			}
+		}
+	}()
+	return nil
+}
+
+// handleOwnerChanged updates the stack state for a new owner of org.bluez,
+// which is "" if bluetoothd stopped.
+func handleOwnerChanged(owner string) {
+	stack.lock.Lock()
+	if owner == stack.owner {
+		stack.lock.Unlock()
+		return
+	}
+	stopped := stack.owner != ""
+	if stopped {
+		// The old process is gone, and with it all of its objects.
+		close(stack.lost)
+		stack.lost = make(chan struct{})
+	}
+	stack.owner = owner
+	stack.lock.Unlock()
+
+	if stopped {
+		notifyStack(StackEvent{Type: StackStopped})
+	}
+	if owner == "" {
+		return
+	}
+	errs := restoreStack()
+	notifyStack(StackEvent{Type: StackRestarted, Owner: owner, Errors: errs})
+}
+
+// restoreStack acquires the adapter proxies again and registers everything
+// that bluetoothd forgot.
+func restoreStack() []error {
+	stack.lock.Lock()
+	var adapters []*Adapter
+	for a := range stack.adapters {
+		adapters = append(adapters, a)
+	}
+	var hooks []*stackHook
+	for hook := range stack.hooks {
+		hooks = append(hooks, hook)
+	}
+	stack.lock.Unlock()
+
+	var errs []error
+	for _, a := range adapters {
+		if err := a.restore(); err != nil {
+			log.Println("TinyGo restore adapter", a.id, err)
+			errs = append(errs, err)
+		}
+	}
+	for _, hook := range hooks {
+		if err := hook.fn(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// restore acquires the adapter proxy again after bluetoothd restarted,
+// restarts discovery if it was requested and registers the advertisement
+// again.
+func (a *Adapter) restore() error {
+	// Bypass the cache in the api package, which holds the old proxy.
+	proxy, err := adapter.GetAdapter(a.id)
+	if err != nil {
+		return err
+	}
+	a.setProxy(proxy)
+
+	// The discovery session ended with the old process. The watcher of the
+	// Discovering property keeps working, as its match rule does not depend
+	// on the process.
+	c := &a.discovery
+	c.lock.Lock()
+	c.started = false
+	c.lock.Unlock()
+	err = a.reconcileDiscovery()
+	if err != nil {
+		return err
+	}
+
+	if adv := a.advertisement(); adv != nil {
+		adv.lock.Lock()
+		defer adv.lock.Unlock()
+		if adv.started {
+			return adv.expose()
+		}
+	}
+	return nil
+}
+
+// notifyStack sends an event to all watchers without blocking.
+func notifyStack(event StackEvent) {
+	stack.lock.Lock()
+	defer stack.lock.Unlock()
+	for ch := range stack.watchers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,72 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"testing"
+)
+
+func TestStackRestart(t *testing.T) {
+	stack.lock.Lock()
+	stack.owner = ":1.5"
+	stack.lock.Unlock()
+
+	events := make(chan StackEvent, 4)
+	stack.lock.Lock()
+	stack.watchers[events] = struct{}{}
+	stack.lock.Unlock()
+	defer func() {
+		stack.lock.Lock()
+		delete(stack.watchers, events)
+		stack.owner = ""
+		stack.lock.Unlock()
+	}()
+
+	hookCalls := 0
+	remove := OnStackRestarted(func() error {
+		hookCalls++
+		return nil
+	})
+	defer remove()
+
+	handle := currentStack()
+	d := &Device{stack: handle}
+	if err := handle.check(); err != nil {
+		t.Fatalf("fresh handle is stale: %v", err)
+	}
+	if err := (stackHandle{}).check(); err != nil {
+		t.Errorf("zero handle is stale: %v", err)
+	}
+
+	handleOwnerChanged("")
+	if err := d.stack.check(); err != ErrStackRestarted {
+		t.Errorf("expected ErrStackRestarted but got %v", err)
+	}
+	if _, err := (DeviceCharacteristic{device: d}).WriteWithoutResponse([]byte{1}); err != ErrStackRestarted {
+		t.Errorf("expected ErrStackRestarted from a stale characteristic but got %v", err)
+	}
+	if event := <-events; event.Type != StackStopped {
+		t.Errorf("expected StackStopped but got %+v", event)
+	}
+
+	handleOwnerChanged(":1.9")
+	if event := <-events; event.Type != StackRestarted || event.Owner != ":1.9" || len(event.Errors) != 0 {
+		t.Errorf("expected StackRestarted but got %+v", event)
+	}
+	if hookCalls != 1 {
+		t.Errorf("expected the hook to be called once, got %d", hookCalls)
+	}
+	if err := currentStack().check(); err != nil {
+		t.Errorf("handle after the restart is stale: %v", err)
+	}
+
+	// A direct replacement both stops and restarts.
+	handleOwnerChanged(":1.12")
+	if event := <-events; event.Type != StackStopped {
+		t.Errorf("expected StackStopped but got %+v", event)
+	}
+	if event := <-events; event.Type != StackRestarted {
+		t.Errorf("expected StackRestarted but got %+v", event)
+	}
+}
@@ -0,0 +1,378 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/GKoSon/gobluetooth/watchdog"
+)
+
+var (
+	errConnectStalled    = errors.New("bluetooth: connection attempt did not finish in time")
+	errSupervisorRunning = errors.New("bluetooth: supervisor is already running")
+)
+
+// StallKind is the kind of problem that made a Supervisor start a recovery.
+type StallKind uint8
+
+const (
+	// StallScan means no scan results arrived within the scan timeout while
+	// discovery was requested.
+	StallScan StallKind = iota + 1
+
+	// StallConnect means a connection attempt did not finish before the
+	// connect timeout.
+	StallConnect
+
+	// StallDBus means a D-Bus call to BlueZ did not return before the call
+	// timeout.
+	StallDBus
+
+	// StallReported means the application reported a stall with
+	// Supervisor.Stall.
+	StallReported
+)
+
+// String returns a short description of the stall.
+func (k StallKind) String() string {
+	switch k {
+	case StallScan:
+		return "no scan results"
+	case StallConnect:
+		return "connect timeout"
+	case StallDBus:
+		return "D-Bus timeout"
+	case StallReported:
+		return "reported"
+	default:
+		return "unknown"
+	}
+}
+
+// RecoveryStep is one step a Supervisor takes to recover from a stall. The
+// steps are ordered from least to most disruptive.
+type RecoveryStep struct {
+	Name string
+	Run  func(ctx context.Context, a *Adapter) error
+}
+
+var (
+	// RestartDiscoveryStep stops and restarts discovery.
+	RestartDiscoveryStep = RecoveryStep{"restart discovery", func(ctx context.Context, a *Adapter) error {
+		return a.restartDiscovery()
+	}}
+
+	// ResetAdapterStep resets the HCI controller, see Adapter.Reset.
+	ResetAdapterStep = RecoveryStep{"reset adapter", func(ctx context.Context, a *Adapter) error {
+		return a.Reset()
+	}}
+
+	// FlushUnbondedStep removes cached devices that are not bonded, see
+	// Adapter.FlushUnbonded.
+	FlushUnbondedStep = RecoveryStep{"flush unbonded devices", func(ctx context.Context, a *Adapter) error {
+		return a.FlushUnbonded()
+	}}
+)
+
+// RestartHookStep returns a step that calls an external hook, for example one
+// that restarts the bluetooth service when nothing else helped.
+func RestartHookStep(name string, hook func(ctx context.Context) error) RecoveryStep {
+	return RecoveryStep{name, func(ctx context.Context, a *Adapter) error {
+		return hook(ctx)
+	}}
+}
+
+// RecoveryEvent reports a recovery step taken by a Supervisor.
+type RecoveryEvent struct {
+	// Time is when the step was started.
+	Time time.Time
+
+	// Stall is the problem that triggered the recovery.
+	Stall StallKind
+
+	// Level is the index of the step in SupervisorOptions.Steps, and Step
+	// its name.
+	Level int
+	Step  string
+
+	// Duration is how long the step took and Err the error it returned, if
+	// any.
+	Duration time.Duration
+	Err      error
+}
+
+// SupervisorOptions configures a Supervisor.
+type SupervisorOptions struct {
+	// ScanTimeout is how long discovery may run without any scan results
+	// before it counts as a stall. Scan results must be passed to
+	// Supervisor.HandleScanResult. Zero disables this check.
+	ScanTimeout time.Duration
+
+	// ConnectTimeout is how long Supervisor.Connect waits for a connection.
+	// The default is 30 seconds.
+	ConnectTimeout time.Duration
+
+	// CallTimeout is how long a D-Bus call to BlueZ may take before it
+	// counts as a stall. A call is made every ProbeInterval, 30 seconds by
+	// default. Zero disables this check.
+	CallTimeout   time.Duration
+	ProbeInterval time.Duration
+
+	// Steps are taken one after another for stalls that follow each other.
+	// Once the last step is reached it is repeated. The default is
+	// RestartDiscoveryStep, ResetAdapterStep and FlushUnbondedStep.
+	Steps []RecoveryStep
+
+	// StepTimeout limits the time a step may take. The default is 30
+	// seconds.
+	StepTimeout time.Duration
+
+	// ResetAfter is how long there must be no stall before the next stall
+	// starts again with the first step. The default is ten minutes.
+	ResetAfter time.Duration
+
+	// Report is called after every recovery step. It is optional.
+	Report func(RecoveryEvent)
+}
+
+// Supervisor watches an adapter for stalls and escalates through recovery
+// steps until it works again.
+type Supervisor struct {
+	adapter *Adapter
+	options SupervisorOptions
+	stalls  chan StallKind
+
+	lock      sync.Mutex
+	level     int
+	lastStall time.Time
+	scanDog   *watchdog.Watchdog
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewSupervisor returns a new Supervisor for this adapter. Call Start to start
+// watching.
+func (a *Adapter) NewSupervisor(options SupervisorOptions) *Supervisor {
+	if options.ConnectTimeout <= 0 {
+		options.ConnectTimeout = 30 * time.Second
+	}
+	if options.ProbeInterval <= 0 {
+		options.ProbeInterval = 30 * time.Second
+	}
+	if len(options.Steps) == 0 {
+		options.Steps = []RecoveryStep{RestartDiscoveryStep, ResetAdapterStep, FlushUnbondedStep}
+	}
+	if options.StepTimeout <= 0 {
+		options.StepTimeout = 30 * time.Second
+	}
+	if options.ResetAfter <= 0 {
+		options.ResetAfter = 10 * time.Minute
+	}
+	return &Supervisor{
+		adapter: a,
+		options: options,
+		stalls:  make(chan StallKind, 1),
+	}
+}
+
+// Start starts watching the adapter.
+func (s *Supervisor) Start() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.cancel != nil {
+		return errSupervisorRunning
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	if s.options.ScanTimeout > 0 {
+		s.scanDog = watchdog.New(s.options.ScanTimeout, s.scanTimeout)
+	}
+	go s.run(ctx)
+	return nil
+}
+
+// Stop stops watching the adapter and waits for a running recovery step to
+// finish or time out.
+func (s *Supervisor) Stop() {
+	s.lock.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel = nil
+	if s.scanDog != nil {
+		s.scanDog.Stop()
+		s.scanDog = nil
+	}
+	s.lock.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Level returns the index of the step that the next stall will take.
+func (s *Supervisor) Level() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.level
+}
+
+// HandleScanResult feeds the scan watchdog. Pass it to Scan, or call it from
+// the scan callback.
+func (s *Supervisor) HandleScanResult(a *Adapter, result ScanResult) {
+	s.feedScan()
+}
+
+// feedScan restarts the scan timeout.
+func (s *Supervisor) feedScan() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.scanDog != nil {
+		s.scanDog.Feed()
+	}
+}
+
+// scanTimeout is called by the scan watchdog.
+func (s *Supervisor) scanTimeout() {
+	if s.adapter.DiscoveryState().Requests > 0 {
+		s.Stall(StallScan)
+	}
+	// Keep checking, also when discovery is not requested right now.
+	s.feedScan()
+}
+
+// Stall starts a recovery. Stalls that are reported while a recovery step is
+// running are ignored.
+func (s *Supervisor) Stall(kind StallKind) {
+	select {
+	case s.stalls <- kind:
+	default:
+	}
+}
+
+// Connect connects to a device like Adapter.Connect, but gives up after the
+// connect timeout and treats that as a stall. A connection that is made after
+// giving up is disconnected again.
+func (s *Supervisor) Connect(address Addresser, params ConnectionParams) (*Device, error) {
+	type result struct {
+		device *Device
+		err    error
+	}
+	results := make(chan result, 1)
+	go func() {
+		device, err := s.adapter.Connect(address, params)
+		results <- result{device, err}
+	}()
+
+	timer := time.NewTimer(s.options.ConnectTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-results:
+		return r.device, r.err
+	case <-timer.C:
+		s.Stall(StallConnect)
+		go func() {
+			if r := <-results; r.err == nil {
+				r.device.Disconnect()
+			}
+		}()
+		return nil, errConnectStalled
+	}
+}
+
+// run takes the recovery steps and probes D-Bus until the context is
+// canceled.
+func (s *Supervisor) run(ctx context.Context) {
+	defer close(s.done)
+	var probe <-chan time.Time
+	if s.options.CallTimeout > 0 {
+		ticker := time.NewTicker(s.options.ProbeInterval)
+		defer ticker.Stop()
+		probe = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case kind := <-s.stalls:
+			s.recover(ctx, kind)
+		case <-probe:
+			if !s.probe(ctx) {
+				s.recover(ctx, StallDBus)
+			}
+		}
+	}
+}
+
+// probe makes a D-Bus call to BlueZ and returns whether it returned in time.
+// An error counts as a returned call.
+func (s *Supervisor) probe(ctx context.Context) bool {
+	returned := make(chan struct{}, 1)
+	go func() {
+		s.adapter.Powered()
+		returned <- struct{}{}
+	}()
+	timer := time.NewTimer(s.options.CallTimeout)
+	defer timer.Stop()
+	select {
+	case <-returned:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return true
+	}
+}
+
+// nextStep returns the step for a stall at the given time and escalates.
+func (s *Supervisor) nextStep(now time.Time) (int, RecoveryStep) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.lastStall.IsZero() && now.Sub(s.lastStall) >= s.options.ResetAfter {
+		s.level = 0
+	}
+	s.lastStall = now
+	level := s.level
+	if level >= len(s.options.Steps) {
+		level = len(s.options.Steps) - 1
+	} else {
+		s.level++
+	}
+	return level, s.options.Steps[level]
+}
+
+// recover takes the next recovery step and reports it.
+func (s *Supervisor) recover(ctx context.Context, kind StallKind) {
+	start := time.Now()
+	level, step := s.nextStep(start)
+
+	stepCtx, cancel := context.WithTimeout(ctx, s.options.StepTimeout)
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- step.Run(stepCtx, s.adapter)
+	}()
+	var err error
+	select {
+	case err = <-errs:
+	case <-stepCtx.Done():
+		err = stepCtx.Err()
+	}
+
+	// Give the adapter a full scan timeout to come back.
+	s.feedScan()
+	if s.options.Report != nil {
+		s.options.Report(RecoveryEvent{
+			Time:     start,
+			Stall:    kind,
+			Level:    level,
+			Step:     step.Name,
+			Duration: time.Since(start),
+			Err:      err,
+		})
+	}
+}
@@ -0,0 +1,59 @@
+//go:build !baremetal
+// +build !baremetal
+
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSupervisorEscalation(t *testing.T) {
+	var ran []string
+	var events []RecoveryEvent
+	step := func(name string, err error) RecoveryStep {
+		return RecoveryStep{name, func(ctx context.Context, a *Adapter) error {
+			ran = append(ran, name)
+			return err
+		}}
+	}
+	errRestart := errors.New("restart failed")
+	hang := RecoveryStep{"hang", func(ctx context.Context, a *Adapter) error {
+		<-ctx.Done()
+		return nil
+	}}
+	s := NewAdapter("hci0").NewSupervisor(SupervisorOptions{
+		Steps:       []RecoveryStep{step("discovery", nil), step("restart", errRestart), hang},
+		StepTimeout: 10 * time.Millisecond,
+		Report: func(event RecoveryEvent) {
+			events = append(events, event)
+		},
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		s.recover(ctx, StallReported)
+	}
+	if len(ran) != 2 || ran[0] != "discovery" || ran[1] != "restart" {
+		t.Errorf("unexpected steps %v", ran)
+	}
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events but got %d", len(events))
+	}
+	for i, want := range []struct {
+		level int
+		err   error
+	}{{0, nil}, {1, errRestart}, {2, context.DeadlineExceeded}, {2, context.DeadlineExceeded}} {
+		if events[i].Level != want.level || events[i].Err != want.err || events[i].Stall != StallReported {
+			t.Errorf("event %d: unexpected %+v", i, events[i])
+		}
+	}
+
+	// After a quiet period, recovery starts at the first step again.
+	s.lastStall = time.Now().Add(-time.Hour)
+	if level, step := s.nextStep(time.Now()); level != 0 || step.Name != "discovery" {
+		t.Errorf("expected the first step after a quiet period, got %d %s", level, step.Name)
+	}
+}
@@ -0,0 +1,119 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type Category struct {
+	Category    uint16        `json:"category"`
+	Name        string        `json:"name"`
+	Subcategory []Subcategory `json:"subcategory"`
+}
+
+type Subcategory struct {
+	Value    uint16 `json:"value"`
+	Name     string `json:"name"`
+	Category *Category
+}
+
+func varName(name string) string {
+	str := strings.ReplaceAll(name, "-", " ")
+	str = strings.Title(str)
+	return strings.ReplaceAll(str, " ", "")
+}
+
+func (c Category) VarName() string {
+	return varName(c.Name)
+}
+
+func (c Category) Value() string {
+	return fmt.Sprintf("0x%04X", c.Category<<6)
+}
+
+func (s Subcategory) VarName() string {
+	return s.Category.VarName() + varName(s.Name)
+}
+
+func (s Subcategory) FullValue() string {
+	return fmt.Sprintf("0x%04X", s.Category.Category<<6|s.Value)
+}
+
+func main() {
+	jsonFile, err := os.Open("data/gap_appearance.json")
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	defer jsonFile.Close()
+
+	data, _ := ioutil.ReadAll(jsonFile)
+
+	var categories []Category
+	json.Unmarshal(data, &categories)
+	for i := range categories {
+		for j := range categories[i].Subcategory {
+			categories[i].Subcategory[j].Category = &categories[i]
+		}
+	}
+
+	packageTemplate := template.Must(template.New("").Parse(tmpl))
+
+	var buf bytes.Buffer
+	err = packageTemplate.Execute(&buf, struct {
+		Timestamp  time.Time
+		Categories []Category
+	}{
+		Timestamp:  time.Now(),
+		Categories: categories,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	err = ioutil.WriteFile("appearance_values.go", src, 0644)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+var tmpl = `// Code generated by bin/gen-appearance; DO NOT EDIT.
+// This file was generated on {{.Timestamp}} from data/gap_appearance.json, a curated
+// subset of the appearance values in
+// https://github.com/NordicSemiconductor/bluetooth-numbers-database/blob/master/v1/gap_appearance.json
+// Values outside the subset are treated as unknown.
+
+package bluetooth
+
+const (
+{{ range .Categories }}
+	// Appearance{{.VarName}} - {{.Name}}
+	Appearance{{.VarName}} Appearance = {{.Value}}
+{{ range .Subcategory }}
+	// Appearance{{.VarName}} - {{.Name}}
+	Appearance{{.VarName}} Appearance = {{.FullValue}}
+{{ end }}{{ end }}
+)
+
+// appearanceNames maps the appearance values to their names. Values without a
+// subcategory have the name of the category.
+var appearanceNames = map[Appearance]string{
+{{ range .Categories }}	Appearance{{.VarName}}: {{printf "%q" .Name}},
+{{ range .Subcategory }}	Appearance{{.VarName}}: {{printf "%q" .Name}},
+{{ end }}{{ end }}}
+`
@@ -94,4 +94,9 @@ var (
 	CharacteristicUUID{{.VarName}} = {{.UUIDFunc}}
 {{ end }}
 )
+
+// characteristicUUIDNames maps the standard characteristic UUIDs to their names.
+var characteristicUUIDNames = map[UUID]string{
+{{ range .Characteristics }}	CharacteristicUUID{{.VarName}}: {{printf "%q" .Name}},
+{{ end }}}
 `
@@ -0,0 +1,73 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"text/template"
+	"time"
+)
+
+type Company struct {
+	Code uint16 `json:"code"`
+	Name string `json:"name"`
+}
+
+func main() {
+	jsonFile, err := os.Open("data/company_ids.json")
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	defer jsonFile.Close()
+
+	data, _ := ioutil.ReadAll(jsonFile)
+
+	var companies []Company
+	json.Unmarshal(data, &companies)
+
+	packageTemplate := template.Must(template.New("").Parse(tmpl))
+
+	var buf bytes.Buffer
+	err = packageTemplate.Execute(&buf, struct {
+		Timestamp time.Time
+		Companies []Company
+	}{
+		Timestamp: time.Now(),
+		Companies: companies,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	err = ioutil.WriteFile("company_ids.go", src, 0644)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+var tmpl = `// Code generated by bin/gen-company-ids; DO NOT EDIT.
+// This file was generated on {{.Timestamp}} from data/company_ids.json, a curated
+// subset of the company identifiers in
+// https://github.com/NordicSemiconductor/bluetooth-numbers-database/blob/master/v1/company_ids.json
+// Values outside the subset are treated as unknown.
+
+package bluetooth
+
+// companyNames maps the company identifiers assigned by the Bluetooth SIG to
+// the names of the companies.
+var companyNames = map[uint16]string{
+{{ range .Companies }}	{{printf "0x%04X" .Code}}: {{printf "%q" .Name}},
+{{ end }}}
+`
@@ -0,0 +1,113 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	bluetooth "github.com/GKoSon/gobluetooth"
+)
+
+type Descriptor struct {
+	Name       string `json:"name"`
+	Identifier string `json:"identifier"`
+	UUID       string `json:"uuid"`
+	Source     string `json:"source"`
+}
+
+func (d Descriptor) VarName() string {
+	str := strings.ReplaceAll(d.Name, " Descriptor", "")
+	str = strings.ReplaceAll(str, ":", "")
+	str = strings.ReplaceAll(str, "-", "")
+	str = strings.ReplaceAll(str, "(", "")
+	str = strings.ReplaceAll(str, ")", "")
+	str = strings.ReplaceAll(str, "/", "")
+	str = strings.Title(str)
+	return strings.ReplaceAll(str, " ", "")
+}
+
+func (d Descriptor) UUIDFunc() string {
+	if len(d.UUID) == 4 {
+		return "New16BitUUID(0x" + d.UUID + ")"
+	}
+	uuid, err := bluetooth.ParseUUID(strings.ToLower(d.UUID))
+	if err != nil {
+		panic(err)
+	}
+	b := uuid.Bytes()
+	bs := hex.EncodeToString(b[:])
+	bss := ""
+	for i := 0; i < len(bs); i += 2 {
+		bss = "0x" + bs[i:i+2] + "," + bss
+	}
+	return "NewUUID([16]byte{" + bss + "})"
+}
+
+func main() {
+	jsonFile, err := os.Open("data/descriptor_uuids.json")
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	defer jsonFile.Close()
+
+	data, _ := ioutil.ReadAll(jsonFile)
+
+	var descriptors []Descriptor
+	json.Unmarshal(data, &descriptors)
+
+	packageTemplate := template.Must(template.New("").Parse(tmpl))
+
+	var buf bytes.Buffer
+	err = packageTemplate.Execute(&buf, struct {
+		Timestamp   time.Time
+		Descriptors []Descriptor
+	}{
+		Timestamp:   time.Now(),
+		Descriptors: descriptors,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	err = ioutil.WriteFile("descriptor_uuids.go", src, 0644)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+var tmpl = `// Code generated by bin/gen-descriptor-uuids; DO NOT EDIT.
+// This file was generated on {{.Timestamp}} from data/descriptor_uuids.json, a curated
+// subset of the descriptor UUIDs in
+// https://github.com/NordicSemiconductor/bluetooth-numbers-database/blob/master/v1/descriptor_uuids.json
+// Values outside the subset are treated as unknown.
+
+package bluetooth
+
+var (
+{{ range .Descriptors }}
+	// DescriptorUUID{{.VarName}} - {{.Name}}
+	DescriptorUUID{{.VarName}} = {{.UUIDFunc}}
+{{ end }}
+)
+
+// descriptorUUIDNames maps the standard descriptor UUIDs to their names.
+var descriptorUUIDNames = map[UUID]string{
+{{ range .Descriptors }}	DescriptorUUID{{.VarName}}: {{printf "%q" .Name}},
+{{ end }}}
+`
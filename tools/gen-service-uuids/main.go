@@ -91,4 +91,9 @@ var (
 	ServiceUUID{{.VarName}} = {{.UUIDFunc}}
 {{ end }}
 )
+
+// serviceUUIDNames maps the standard service UUIDs to their names.
+var serviceUUIDNames = map[UUID]string{
+{{ range .Services }}	ServiceUUID{{.VarName}}: {{printf "%q" .Name}},
+{{ end }}}
 `
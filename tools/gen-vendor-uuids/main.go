@@ -0,0 +1,297 @@
+// Command gen-vendor-uuids generates Go code for proprietary service,
+// characteristic and descriptor UUIDs from a JSON or YAML definition, so that
+// they do not have to be parsed (and possibly mistyped) at startup.
+//
+// Use it with go generate:
+//
+//	//go:generate go run github.com/GKoSon/gobluetooth/tools/gen-vendor-uuids -in acme.yaml -out acme_uuids.go -package acme
+//
+// The definition looks like this, in YAML or the equivalent JSON:
+//
+//	name: Acme
+//	services:
+//	  - name: Acme Sensor
+//	    uuid: 6e400001-b5a3-f393-e0a9-e50e24dcca9e
+//	    characteristics:
+//	      - name: Acme Temperature
+//	        uuid: 6e400002-b5a3-f393-e0a9-e50e24dcca9e
+//	        format: sint16
+//	        descriptors:
+//	          - name: Acme Calibration
+//	            uuid: 6e400003-b5a3-f393-e0a9-e50e24dcca9e
+//
+// For every entry a variable such as ServiceUUIDAcmeSensor is generated, and
+// the UUIDs are registered with bluetooth.RegisterUUIDs so that
+// bluetooth.ServiceName and friends know their names. UUIDs may be 16-bit
+// ("fe59") or 128-bit, in any case. Names must start with a letter.
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/GKoSon/gobluetooth"
+	"gopkg.in/yaml.v3"
+)
+
+type Definition struct {
+	Name     string    `json:"name" yaml:"name"`
+	Services []Service `json:"services" yaml:"services"`
+}
+
+type Service struct {
+	Name            string           `json:"name" yaml:"name"`
+	UUID            string           `json:"uuid" yaml:"uuid"`
+	Characteristics []Characteristic `json:"characteristics" yaml:"characteristics"`
+}
+
+type Characteristic struct {
+	Name        string       `json:"name" yaml:"name"`
+	UUID        string       `json:"uuid" yaml:"uuid"`
+	Format      string       `json:"format" yaml:"format"`
+	Descriptors []Descriptor `json:"descriptors" yaml:"descriptors"`
+}
+
+type Descriptor struct {
+	Name   string `json:"name" yaml:"name"`
+	UUID   string `json:"uuid" yaml:"uuid"`
+	Format string `json:"format" yaml:"format"`
+}
+
+// Entry is a single generated variable.
+type Entry struct {
+	VarName  string
+	Name     string
+	Kind     string
+	Format   string
+	UUIDFunc string
+}
+
+func main() {
+	in := flag.String("in", "", "input definition, .json or .yaml")
+	out := flag.String("out", "", "output Go file")
+	pkg := flag.String("package", "", "package name of the output file (default: the GOPACKAGE set by go generate)")
+	flag.Parse()
+	if *pkg == "" {
+		*pkg = os.Getenv("GOPACKAGE")
+	}
+	if *in == "" || *out == "" || *pkg == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err := run(*in, *out, *pkg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gen-vendor-uuids:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg string) error {
+	data, err := ioutil.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	var def Definition
+	if ext := filepath.Ext(in); ext == ".yaml" || ext == ".yml" {
+		err = yaml.Unmarshal(data, &def)
+	} else {
+		err = json.Unmarshal(data, &def)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", in, err)
+	}
+	src, err := generate(def, filepath.Base(in), pkg)
+	if err != nil {
+		return fmt.Errorf("%s: %v", in, err)
+	}
+	return ioutil.WriteFile(out, src, 0644)
+}
+
+// generate returns the formatted Go source for the definition, which was read
+// from the file source.
+func generate(def Definition, source, pkg string) ([]byte, error) {
+	if def.Name == "" {
+		def.Name = "Vendor"
+	}
+	if err := checkName(def.Name); err != nil {
+		return nil, err
+	}
+
+	qualifier := "bluetooth."
+	if pkg == "bluetooth" {
+		qualifier = ""
+	}
+	entries, err := makeEntries(def, qualifier)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	err = template.Must(template.New("").Parse(tmpl)).Execute(buf, struct {
+		Source    string
+		Package   string
+		Qualifier string
+		Prefix    string
+		Entries   []Entry
+	}{
+		Source:    source,
+		Package:   pkg,
+		Qualifier: qualifier,
+		Prefix:    varName(def.Name),
+		Entries:   entries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// makeEntries validates the definition and returns the variables to generate.
+func makeEntries(def Definition, qualifier string) ([]Entry, error) {
+	var entries []Entry
+	seen := make(map[string]bool)
+	add := func(prefix, kind, name, uuid, format string) error {
+		if name == "" {
+			return fmt.Errorf("%s %s has no name", strings.ToLower(kind), uuid)
+		}
+		if err := checkName(name); err != nil {
+			return fmt.Errorf("%s %s: %v", strings.ToLower(kind), uuid, err)
+		}
+		uuidFunc, err := uuidFunc(uuid, qualifier)
+		if err != nil {
+			return fmt.Errorf("%s %q: %v", strings.ToLower(kind), name, err)
+		}
+		entry := Entry{
+			VarName:  prefix + varName(name),
+			Name:     name,
+			Kind:     kind,
+			Format:   format,
+			UUIDFunc: uuidFunc,
+		}
+		if seen[entry.VarName] {
+			return fmt.Errorf("duplicate name %s", entry.VarName)
+		}
+		seen[entry.VarName] = true
+		entries = append(entries, entry)
+		return nil
+	}
+	for _, s := range def.Services {
+		err := add("ServiceUUID", "Service", s.Name, s.UUID, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range s.Characteristics {
+			err := add("CharacteristicUUID", "Characteristic", c.Name, c.UUID, c.Format)
+			if err != nil {
+				return nil, err
+			}
+			for _, d := range c.Descriptors {
+				err := add("DescriptorUUID", "Descriptor", d.Name, d.UUID, d.Format)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return entries, nil
+}
+
+// checkName returns an error if the name does not start with a letter, as the
+// identifier made from it would lose its first characters.
+func checkName(name string) error {
+	if c := name[0]; !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+		return fmt.Errorf("name %q does not start with a letter", name)
+	}
+	return nil
+}
+
+// varName turns a name like "Acme Sensor" into an identifier like
+// "AcmeSensor". The name must start with a letter.
+func varName(name string) string {
+	var sb strings.Builder
+	upper := true
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z':
+			if upper {
+				c -= 'a' - 'A'
+			}
+			sb.WriteRune(c)
+			upper = false
+		case c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' && sb.Len() != 0:
+			sb.WriteRune(c)
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	return sb.String()
+}
+
+// uuidFunc returns the Go expression for the given UUID, which may be in any
+// form accepted by bluetooth.ParseUUID.
+func uuidFunc(s, qualifier string) (string, error) {
+	uuid, err := bluetooth.ParseUUID(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid UUID %q", s)
+	}
+	switch {
+	case uuid.Is16Bit():
+		return fmt.Sprintf("%sNew16BitUUID(0x%04X)", qualifier, uuid.Get16Bit()), nil
+	case uuid.Is32Bit():
+		return fmt.Sprintf("%sNew32BitUUID(0x%08X)", qualifier, uuid.Get32Bit()), nil
+	}
+	b := uuid.Bytes()
+	bs := hex.EncodeToString(b[:])
+	bss := ""
+	for i := 0; i < len(bs); i += 2 {
+		bss = "0x" + bs[i:i+2] + ", " + bss
+	}
+	return qualifier + "NewUUID([16]byte{" + strings.TrimSuffix(bss, ", ") + "})", nil
+}
+
+var tmpl = `// Code generated by gen-vendor-uuids from {{.Source}}; DO NOT EDIT.
+
+package {{.Package}}
+
+{{ if .Qualifier }}import "github.com/GKoSon/gobluetooth"
+{{ end }}
+var (
+{{- range $i, $e := .Entries }}{{ if $i }}
+{{ end }}
+	// {{.VarName}} - {{.Name}}
+	{{.VarName}} = {{.UUIDFunc}}
+{{- end }}
+)
+
+// {{.Prefix}}UUIDs lists the UUIDs of this file. They are registered with
+// {{.Qualifier}}RegisterUUIDs when the package is initialized.
+var {{.Prefix}}UUIDs = []{{.Qualifier}}UUIDInfo{
+{{ range .Entries }}	{UUID: {{.VarName}}, Name: {{printf "%q" .Name}}, Kind: {{$.Qualifier}}UUIDKind{{.Kind}}{{ if .Format }}, Format: {{printf "%q" .Format}}{{ end }}},
+{{ end }}}
+
+// {{.Prefix}}UUIDName returns the name of one of the UUIDs of this file, or ""
+// if it is not one of them.
+func {{.Prefix}}UUIDName(uuid {{.Qualifier}}UUID) string {
+	for _, info := range {{.Prefix}}UUIDs {
+		if info.UUID == uuid {
+			return info.Name
+		}
+	}
+	return ""
+}
+
+func init() {
+	{{.Qualifier}}RegisterUUIDs({{.Prefix}}UUIDs...)
+}
+`
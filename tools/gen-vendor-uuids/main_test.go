@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// TestGenerate generates code for testdata/acme.yaml, which has 16-bit,
+// 32-bit and 128-bit UUIDs, and compares it with testdata/acme_uuids.go.golden.
+func TestGenerate(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "acme.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var def Definition
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(def, "acme.yaml", "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "acme_uuids.go.golden")
+	if *update {
+		if err := ioutil.WriteFile(golden, src, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Errorf("output differs from %s, run go test -update to see the difference:\n%s", golden, src)
+	}
+}
+
+func TestMakeEntriesErrors(t *testing.T) {
+	tests := []struct {
+		def Definition
+		err string
+	}{
+		{Definition{Services: []Service{
+			{Name: "Acme Sensor", UUID: "fe59"},
+			{Name: "Acme-Sensor", UUID: "fe5a"},
+		}}, "duplicate name ServiceUUIDAcmeSensor"},
+		{Definition{Services: []Service{
+			{Name: "Acme Sensor", UUID: "fe59", Characteristics: []Characteristic{
+				{Name: "2nd Thing", UUID: "fe5a"},
+			}},
+		}}, "does not start with a letter"},
+		{Definition{Services: []Service{
+			{UUID: "fe59"},
+		}}, "has no name"},
+		{Definition{Services: []Service{
+			{Name: "Acme Sensor", UUID: "fe5"},
+		}}, "invalid UUID"},
+	}
+	for _, tc := range tests {
+		_, err := makeEntries(tc.def, "bluetooth.")
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%+v: expected an error with %q, got %v", tc.def, tc.err, err)
+		}
+	}
+}
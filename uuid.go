@@ -43,6 +43,12 @@ func (uuid UUID) Is32Bit() bool {
 	return uuid[0] == 0x5F9B34FB && uuid[1] == 0x80000080 && uuid[2] == 0x00001000
 }
 
+// Get32Bit returns the 32-bit version of this UUID. This is only valid if it
+// actually is a 32-bit UUID, see Is32Bit.
+func (uuid UUID) Get32Bit() uint32 {
+	return uuid[3]
+}
+
 // Get16Bit returns the 16-bit version of this UUID. This is only valid if it
 // actually is a 16-bit UUID, see Is16Bit.
 func (uuid UUID) Get16Bit() uint16 {
@@ -73,12 +79,31 @@ func (uuid UUID) Bytes() [16]byte {
 	return buf
 }
 
-// ParseUUID parses the given UUID, which must be in
-// 00001234-0000-1000-8000-00805f9b34fb format. This means that it cannot (yet)
-// parse 16-bit UUIDs unless they are serialized as a 128-bit UUID. If the UUID
-// cannot be parsed, an error is returned. It will always successfully parse
-// UUIDs generated by UUID.String().
+// ParseUUID parses the given UUID. It accepts the common forms, in upper or
+// lower case:
+//
+//	00001234-0000-1000-8000-00805f9b34fb
+//	{00001234-0000-1000-8000-00805F9B34FB}
+//	0000123400001000800000805f9b34fb
+//	1234, 0x1234 (16-bit)
+//	00001234, 0x00001234 (32-bit)
+//
+// Short forms are expanded with the Bluetooth base UUID. If the UUID cannot be
+// parsed, an error is returned. It will always successfully parse UUIDs
+// generated by UUID.String().
 func ParseUUID(s string) (uuid UUID, err error) {
+	if len(s) >= 2 && s[0] == '{' && s[len(s)-1] == '}' {
+		s = s[1 : len(s)-1]
+	}
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		// Only short forms are written with a 0x prefix.
+		s = s[2:]
+		if len(s) != 4 && len(s) != 8 {
+			err = errInvalidUUID
+			return
+		}
+	}
+	var nibbles [32]byte
 	uuidIndex := 0
 	for i := 0; i < len(s); i++ {
 		c := s[i]
@@ -90,6 +115,8 @@ func ParseUUID(s string) (uuid UUID, err error) {
 			nibble = c - '0' + 0x0
 		} else if c >= 'a' && c <= 'f' {
 			nibble = c - 'a' + 0xa
+		} else if c >= 'A' && c <= 'F' {
+			nibble = c - 'A' + 0xa
 		} else {
 			err = errInvalidUUID
 			return
@@ -98,12 +125,26 @@ func ParseUUID(s string) (uuid UUID, err error) {
 			err = errInvalidUUID
 			return
 		}
-		uuid[3-uuidIndex/8] |= uint32(nibble) << (4 * (7 - uuidIndex%8))
+		nibbles[uuidIndex] = nibble
 		uuidIndex++
 	}
-	if uuidIndex != 32 {
-		// The UUID doesn't have exactly 32 nibbles. Perhaps a 16-bit or 32-bit
-		// UUID?
+	switch uuidIndex {
+	case 4, 8:
+		// A 16-bit or 32-bit UUID. Dashes are not allowed here.
+		if uuidIndex != len(s) {
+			err = errInvalidUUID
+			return
+		}
+		var shortUUID uint32
+		for _, nibble := range nibbles[:uuidIndex] {
+			shortUUID = shortUUID<<4 | uint32(nibble)
+		}
+		uuid = New32BitUUID(shortUUID)
+	case 32:
+		for i, nibble := range nibbles {
+			uuid[3-i/8] |= uint32(nibble) << (4 * (7 - i%8))
+		}
+	default:
 		err = errInvalidUUID
 	}
 	return
@@ -112,34 +153,80 @@ func ParseUUID(s string) (uuid UUID, err error) {
 // String returns a human-readable version of this UUID, such as
 // 00001234-0000-1000-8000-00805f9b34fb.
 func (uuid UUID) String() string {
-	// TODO: make this more efficient.
-	s := ""
+	var buf [36]byte
+	return string(uuid.AppendString(buf[:0]))
+}
+
+// AppendString appends the string form of this UUID, as returned by String,
+// to buf and returns the extended buffer. It does not allocate if buf has
+// enough capacity.
+func (uuid UUID) AppendString(buf []byte) []byte {
+	const hexDigits = "0123456789abcdef"
 	raw := uuid.Bytes()
 	for i := range raw {
 		// Insert a hyphen at the correct locations.
 		if i == 4 || i == 6 || i == 8 || i == 10 {
-			s += "-"
+			buf = append(buf, '-')
 		}
 
 		// The character to convert to hex.
 		c := raw[15-i]
+		buf = append(buf, hexDigits[c>>4], hexDigits[c&0x0f])
+	}
+	return buf
+}
 
-		// First nibble.
-		nibble := c >> 4
-		if nibble <= 9 {
-			s += string(nibble + '0')
-		} else {
-			s += string(nibble + 'a' - 10)
-		}
+// ShortString returns the shortest form of this UUID: four hex digits for a
+// 16-bit UUID such as "180d", eight for a 32-bit UUID, and the full form of
+// String otherwise. ParseUUID accepts all of these.
+func (uuid UUID) ShortString() string {
+	const hexDigits = "0123456789abcdef"
+	var digits int
+	switch {
+	case uuid.Is16Bit():
+		digits = 4
+	case uuid.Is32Bit():
+		digits = 8
+	default:
+		return uuid.String()
+	}
+	var buf [8]byte
+	for i := 0; i < digits; i++ {
+		buf[i] = hexDigits[uuid[3]>>(4*uint(digits-1-i))&0xf]
+	}
+	return string(buf[:digits])
+}
 
-		// Second nibble.
-		nibble = c & 0x0f
-		if nibble <= 9 {
-			s += string(nibble + '0')
-		} else {
-			s += string(nibble + 'a' - 10)
-		}
+// MarshalText returns the string form of this UUID. It implements
+// encoding.TextMarshaler.
+func (uuid UUID) MarshalText() ([]byte, error) {
+	return uuid.AppendString(make([]byte, 0, 36)), nil
+}
+
+// UnmarshalText parses a UUID in any form accepted by ParseUUID. It
+// implements encoding.TextUnmarshaler.
+func (uuid *UUID) UnmarshalText(text []byte) error {
+	u, err := ParseUUID(string(text))
+	if err != nil {
+		return err
 	}
+	*uuid = u
+	return nil
+}
+
+// MarshalJSON returns the string form of this UUID as a JSON string.
+func (uuid UUID) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 0, 38)
+	buf = append(buf, '"')
+	buf = uuid.AppendString(buf)
+	return append(buf, '"'), nil
+}
 
-	return s
+// UnmarshalJSON parses a JSON string with a UUID in any form accepted by
+// ParseUUID.
+func (uuid *UUID) UnmarshalJSON(data []byte) error {
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return errInvalidUUID
+	}
+	return uuid.UnmarshalText(data[1 : len(data)-1])
 }
@@ -15,3 +15,13 @@ func New16BitUUID(shortUUID uint16) UUID {
 	uuid[3] = uint32(shortUUID)
 	return uuid
 }
+
+// New32BitUUID returns a new 128-bit UUID based on a 32-bit UUID.
+func New32BitUUID(shortUUID uint32) UUID {
+	var uuid UUID
+	uuid[0] = 0x5F9B34FB
+	uuid[1] = 0x80000080
+	uuid[2] = 0x00001000
+	uuid[3] = shortUUID
+	return uuid
+}
@@ -1,6 +1,10 @@
 package bluetooth
 
 import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -38,3 +42,113 @@ func TestStringUUID(t *testing.T) {
 		t.Errorf("expected %s but got %s", uuidString, u.String())
 	}
 }
+
+func TestParseUUIDForms(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string
+	}{
+		{"00001234-0000-1000-8000-00805f9b34fb", "00001234-0000-1000-8000-00805f9b34fb"},
+		{"00001234-0000-1000-8000-00805F9B34FB", "00001234-0000-1000-8000-00805f9b34fb"},
+		{"{00001234-0000-1000-8000-00805f9b34fb}", "00001234-0000-1000-8000-00805f9b34fb"},
+		{"0000123400001000800000805f9b34fb", "00001234-0000-1000-8000-00805f9b34fb"},
+		{"180d", "0000180d-0000-1000-8000-00805f9b34fb"},
+		{"0x180D", "0000180d-0000-1000-8000-00805f9b34fb"},
+		{"1234abcd", "1234abcd-0000-1000-8000-00805f9b34fb"},
+		{"0x1234ABCD", "1234abcd-0000-1000-8000-00805f9b34fb"},
+	} {
+		u, err := ParseUUID(tc.in)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.in, err)
+			continue
+		}
+		if u.String() != tc.want {
+			t.Errorf("%s: expected %s but got %s", tc.in, tc.want, u.String())
+		}
+	}
+	for _, in := range []string{"", "18", "180", "180d0", "0x", "0x180d-0000", "18-0d", "{180d", "g80d", "0x00001234-0000-1000-8000-00805f9b34fb"} {
+		if _, err := ParseUUID(in); err != errInvalidUUID {
+			t.Errorf("%q: expected errInvalidUUID but got %v", in, err)
+		}
+	}
+}
+
+func TestUUIDShortString(t *testing.T) {
+	for _, tc := range []struct {
+		uuid UUID
+		want string
+	}{
+		{New16BitUUID(0x180d), "180d"},
+		{New32BitUUID(0x1234abcd), "1234abcd"},
+		{NewUUID([16]byte{0x6e, 0x40, 0x00, 0x01, 0xb5, 0xa3, 0xf3, 0x93, 0xe0, 0xa9, 0xe5, 0x0e, 0x24, 0xdc, 0xca, 0x9e}), "6e400001-b5a3-f393-e0a9-e50e24dcca9e"},
+	} {
+		if s := tc.uuid.ShortString(); s != tc.want {
+			t.Errorf("expected %s but got %s", tc.want, s)
+		}
+	}
+}
+
+func TestUUIDMarshal(t *testing.T) {
+	type doc struct {
+		UUID  UUID
+		UUIDs map[UUID]string
+	}
+	in := doc{New16BitUUID(0x180d), map[UUID]string{New16BitUUID(0x2a37): "heart rate"}}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"UUID":"0000180d-0000-1000-8000-00805f9b34fb","UUIDs":{"00002a37-0000-1000-8000-00805f9b34fb":"heart rate"}}`
+	if string(data) != want {
+		t.Errorf("expected %s but got %s", want, data)
+	}
+	var out doc
+	if err := json.Unmarshal([]byte(`{"UUID":"0x180D","UUIDs":{"2a37":"heart rate"}}`), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("expected %v but got %v", in, out)
+	}
+	if err := json.Unmarshal([]byte(`{"UUID":"xyz"}`), &out); err == nil {
+		t.Error("expected an error for an invalid UUID")
+	}
+}
+
+func TestUUIDRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		var raw [16]byte
+		r.Read(raw[:])
+		uuid := NewUUID(raw)
+		if i%4 == 0 {
+			uuid = New32BitUUID(r.Uint32())
+		} else if i%4 == 1 {
+			uuid = New16BitUUID(uint16(r.Uint32()))
+		}
+		for _, s := range []string{uuid.String(), uuid.ShortString(), strings.ToUpper(uuid.String()), "{" + uuid.String() + "}"} {
+			parsed, err := ParseUUID(s)
+			if err != nil {
+				t.Fatalf("%s: unexpected error %v", s, err)
+			}
+			if parsed != uuid {
+				t.Fatalf("%s: parsed as %s", s, parsed)
+			}
+		}
+		text, _ := uuid.MarshalText()
+		var parsed UUID
+		if err := parsed.UnmarshalText(text); err != nil || parsed != uuid {
+			t.Fatalf("%s: text round trip gave %s, %v", text, parsed, err)
+		}
+	}
+}
+
+func TestUUIDAppendStringAllocs(t *testing.T) {
+	uuid := New16BitUUID(0x180d)
+	buf := make([]byte, 0, 36)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = uuid.AppendString(buf[:0])
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations but got %v", allocs)
+	}
+}